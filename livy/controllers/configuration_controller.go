@@ -2,20 +2,133 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"livy/livy/models"
+	"livy/livy/services"
 	"livy/utils"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+type configurationPayload struct {
+	Name        string `json:"name"`
+	Environment string `json:"environment"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+}
+
+func (p configurationPayload) configuration(namespace string) models.Configuration {
+	return models.Configuration{
+		Namespace:   namespace,
+		Environment: p.Environment,
+		ConfigName:  p.Name,
+		Value:       p.Value,
+		Type:        p.Type,
+	}
+}
+
+type batchPayload struct {
+	// Environment is used by the operations that don't set their own
+	Environment string                  `json:"environment"`
+	Operations  []models.BatchOperation `json:"operations"`
+}
+
+type rollbackPayload struct {
+	// Revision is a revision number or "previous"
+	Revision json.RawMessage `json:"revision"`
+}
+
+// revision returns the revision to roll back to, previous is set instead for
+// "previous". A missing or null revision is an error, not a revision 0.
+func (p rollbackPayload) revision() (revision int, previous bool, err error) {
+	switch string(p.Revision) {
+	case `"previous"`:
+		return 0, true, nil
+	case "", "null":
+		return 0, false, errors.New("missing revision")
+	}
+
+	err = json.Unmarshal(p.Revision, &revision)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return revision, false, nil
+}
+
+func readConfigurationPayload(r *http.Request) (configurationPayload, error) {
+	payload := configurationPayload{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return payload, err
+	}
+
+	defer r.Body.Close()
+
+	err = json.Unmarshal(body, &payload)
+	if payload.Environment == "" {
+		payload.Environment = environmentOf(r)
+	}
+
+	return payload, err
+}
+
 func(h *LivyController) getAllConfiguration(w http.ResponseWriter, r *http.Request){
 	if (r.Method != http.MethodGet){
 		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Method", nil)
+		return
+	}
+	if r.URL.Query().Has("asOf") {
+		h.getAllConfigurationAsOf(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := defaultListLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, "Invalid Pagination", nil)
+			return
+		}
+	}
+
+	datas, next, err := h.svc.ListConfiguration(models.ConfigurationQuery{
+		Namespace:   namespaceOf(r),
+		Environment: environmentOf(r),
+		Prefix:      query.Get("prefix"),
+		Contains:    query.Get("contains"),
+		Sort:        query.Get("sort"),
+		Limit:       limit,
+	}, query.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WritePage(w, http.StatusOK, "", datas, next)
+}
+
+// getAllConfigurationAsOf lists the configurations as they were at the asOf
+// query parameter
+func (h *LivyController) getAllConfigurationAsOf(w http.ResponseWriter, r *http.Request) {
+	asOf, err := time.Parse(time.RFC3339, r.URL.Query().Get("asOf"))
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "asOf Must Be An RFC 3339 Timestamp", nil)
+		return
 	}
-	datas,err := h.svc.GetAllConfiguration()
+
+	datas, err := h.svc.GetAllConfigurationAsOf(namespaceOf(r), environmentOf(r), asOf)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, err.Error(), nil)
+		writeError(w, err)
+		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, "", datas)
@@ -24,43 +137,89 @@ func(h *LivyController) getAllConfiguration(w http.ResponseWriter, r *http.Reque
 func(h *LivyController) getConfiguration(w http.ResponseWriter, r *http.Request){
 	if (r.Method != http.MethodGet){
 		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Method", nil)
+		return
 	}
 
 	vars := mux.Vars(r)
-    configname := vars["configname"]
-	
-	datas,err := h.svc.GetConfiguration(configname)
+	configname := vars["configname"]
+
+	datas,err := h.svc.GetConfiguration(namespaceOf(r), environmentOf(r), configname)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, err.Error(), nil)
+		writeError(w, err)
+		return
 	}
 
+	w.Header().Set("ETag", etag(datas.Version))
 	utils.WriteJSON(w, http.StatusOK, "", datas)
 }
 
-func (h *LivyController) createConfiguration(w http.ResponseWriter, r *http.Request) {
-	if (r.Method != http.MethodPost){
-		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Method", nil)
+// getConfigurationTree returns the subtree of the dotted key path as nested
+// objects, or as a map by full name with format=flat
+func (h *LivyController) getConfigurationTree(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	path := vars["path"]
+
+	var datas interface{}
+	var err error
+	switch r.URL.Query().Get("format") {
+	case "", "nested":
+		datas, err = h.svc.GetConfigurationTree(namespaceOf(r), environmentOf(r), path)
+	case "flat":
+		datas, err = h.svc.GetFlatConfiguration(namespaceOf(r), environmentOf(r), path)
+	default:
+		utils.WriteJSON(w, http.StatusBadRequest, "Format Must Be nested Or flat", nil)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) deleteConfigurationTree(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	path := vars["path"]
+
+	deleted, err := h.svcFor(r).DeleteConfigurationTree(namespaceOf(r), environmentOf(r), path)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+		writeError(w, err)
+		return
 	}
 
-	defer r.Body.Close()
+	utils.WriteJSON(w, http.StatusOK, "Configuration Tree Deleted Successfully", deleted)
+}
 
-	var payload map[string]interface{}
-	err = json.Unmarshal(body, &payload)
+func (h *LivyController) resolveConfiguration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	datas, err := h.svc.ResolveConfiguration(namespaceOf(r), environmentOf(r), configname)
 	if err != nil {
-		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) createConfiguration(w http.ResponseWriter, r *http.Request) {
+	if (r.Method != http.MethodPost){
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Method", nil)
+		return
 	}
 
-	configname := payload["name"].(string)
-	value := payload["value"].(string)
+	payload, err := readConfigurationPayload(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
 
-	err = h.svc.InsertConfiguration(configname, value)
+	err = h.svcFor(r).InsertConfiguration(payload.configuration(namespaceOf(r)))
 	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, err.Error(), nil)
+		writeError(w, err)
+		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, "Configuration Created Successfully", nil)
@@ -69,31 +228,208 @@ func (h *LivyController) createConfiguration(w http.ResponseWriter, r *http.Requ
 func (h *LivyController) updateConfiguration(w http.ResponseWriter, r *http.Request) {
 	if (r.Method != http.MethodPut){
 		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Method", nil)
+		return
 	}
 
 	vars := mux.Vars(r)
-    id := vars["id"]
+	id := vars["id"]
+
+	// updates must say which version they change so concurrent writes can't clobber each other
+	version, ok, err := ifMatchOf(r)
+	if !ok {
+		utils.WriteJSON(w, http.StatusPreconditionRequired, "If-Match Header Required", nil)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	payload, err := readConfigurationPayload(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
+
+	configuration := payload.configuration(namespaceOf(r))
+	configuration.Id = id
+	configuration.Version = version
+
+	err = h.svcFor(r).UpdateConfiguration(configuration)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Configuration Updated Successfully", nil)
+}
+
+func (h *LivyController) upsertConfiguration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	payload, err := readConfigurationPayload(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
+
+	payload.Name = configname
+
+	version, ok, err := ifMatchOf(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	configuration := payload.configuration(namespaceOf(r))
+	configuration.Version = version
+
+	// without If-Match only a missing key is written, overwriting one needs
+	// the version that was read. If-None-Match: * asks for a create only.
+	if !ok {
+		err = h.svcFor(r).InsertConfiguration(configuration)
+		switch {
+		case errors.Is(err, services.ErrAlreadyExists) && strings.TrimSpace(r.Header.Get("If-None-Match")) == "*":
+			utils.WriteJSON(w, http.StatusPreconditionFailed, "Configuration Already Exists", nil)
+		case errors.Is(err, services.ErrAlreadyExists):
+			utils.WriteJSON(w, http.StatusPreconditionRequired, "If-Match Header Required", nil)
+		case err != nil:
+			writeError(w, err)
+		default:
+			utils.WriteJSON(w, http.StatusCreated, "Configuration Created Successfully", nil)
+		}
+		return
+	}
+
+	created, err := h.svcFor(r).UpsertConfiguration(configuration)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if created {
+		utils.WriteJSON(w, http.StatusCreated, "Configuration Created Successfully", nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Configuration Updated Successfully", nil)
+}
+
+func (h *LivyController) deleteConfiguration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := h.svcFor(r).DeleteConfiguration(namespaceOf(r), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Configuration Deleted Successfully", nil)
+}
+
+func (h *LivyController) deleteConfigurationByName(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	err := h.svcFor(r).DeleteConfigurationByName(namespaceOf(r), environmentOf(r), configname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Configuration Deleted Successfully", nil)
+}
+
+func (h *LivyController) getConfigurationHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	limit, offset, err := pageOf(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Pagination", nil)
+		return
+	}
+
+	datas, err := h.svc.GetConfigurationHistory(namespaceOf(r), environmentOf(r), configname, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) rollbackConfiguration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+		return
 	}
 
 	defer r.Body.Close()
 
-	var payload map[string]interface{}
+	payload := rollbackPayload{}
 	err = json.Unmarshal(body, &payload)
 	if err != nil {
 		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
 	}
 
-	configname := payload["name"].(string)
-	value := payload["value"].(string)
+	revision, previous, err := payload.revision()
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, `Revision Must Be A Number Or "previous"`, nil)
+		return
+	}
 
-	err = h.svc.UpdateConfiguration(id,configname,value)
+	var datas models.Configuration
+	if previous {
+		datas, err = h.svcFor(r).RollbackToPreviousRevision(namespaceOf(r), environmentOf(r), configname)
+	} else {
+		datas, err = h.svcFor(r).RollbackConfiguration(namespaceOf(r), environmentOf(r), configname, revision)
+	}
 	if err != nil {
-		utils.WriteJSON(w, http.StatusInternalServerError, err.Error(), nil)
+		writeError(w, err)
+		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, "Configuration Updated Successfully", nil)
+	utils.WriteJSON(w, http.StatusOK, "Configuration Rolled Back Successfully", datas)
+}
+
+func (h *LivyController) applyBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+		return
+	}
+
+	defer r.Body.Close()
+
+	payload := batchPayload{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
+
+	if payload.Environment == "" {
+		payload.Environment = environmentOf(r)
+	}
+	for i := range payload.Operations {
+		if payload.Operations[i].Environment == "" {
+			payload.Operations[i].Environment = payload.Environment
+		}
+	}
+
+	err = h.svcFor(r).ApplyBatch(namespaceOf(r), payload.Operations)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Batch Applied Successfully", nil)
 }
@@ -0,0 +1,980 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"livy/livy/migrations"
+	"livy/livy/services"
+	"livy/livy/storages/memory"
+	"livy/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRouter returns the api router backed by a migrated memory storage
+func setupRouter(t *testing.T) *mux.Router {
+	ctx := context.Background()
+	db := memory.New()
+	require.NoError(t, migrations.New(db).Run(ctx))
+
+	return NewController(ctx, services.NewLivySvc(ctx, db)).registerHandler()
+}
+
+func doRequest(t *testing.T, router http.Handler, method, target, body string) (int, utils.WebResponse) {
+	status, _, response := doRequestWithHeader(t, router, method, target, body, nil)
+	return status, response
+}
+
+// doRequestWithHeader sends header with the request and also returns the response headers
+func doRequestWithHeader(t *testing.T, router http.Handler, method, target, body string, header http.Header) (int, http.Header, utils.WebResponse) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	response := utils.WebResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	return rec.Code, rec.Header(), response
+}
+
+// anyVersion lets PUT /configuration/{configname} overwrite an existing key
+// whatever its version
+var anyVersion = http.Header{"If-Match": []string{"*"}}
+
+func TestConfigurationStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		ifMatch        string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "get missing configuration",
+			method:         http.MethodGet,
+			target:         "/api/configuration/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "get existing configuration",
+			method:         http.MethodGet,
+			target:         "/api/configuration/timeout",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "create with invalid json",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"name":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "create without name",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"value":"10"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "create with invalid typed value",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"name":"retries","value":"abc","type":"int"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "create with unknown type",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"name":"retries","value":"3","type":"number"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "create with typed value",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"name":"retries","value":"3","type":"int"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "create duplicate",
+			method:         http.MethodPost,
+			target:         "/api/configuration/create",
+			body:           `{"name":"timeout","value":"20"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "upsert new configuration",
+			method:         http.MethodPut,
+			target:         "/api/configuration/retries",
+			body:           `{"value":"3"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "upsert existing configuration",
+			method:         http.MethodPut,
+			target:         "/api/configuration/timeout",
+			body:           `{"value":"20"}`,
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "upsert existing without If-Match",
+			method:         http.MethodPut,
+			target:         "/api/configuration/timeout",
+			body:           `{"value":"20"}`,
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "upsert existing with If-None-Match",
+			method:         http.MethodPut,
+			target:         "/api/configuration/timeout",
+			body:           `{"value":"20"}`,
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "upsert new with If-None-Match",
+			method:         http.MethodPut,
+			target:         "/api/configuration/retries",
+			body:           `{"value":"3"}`,
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "update unknown id",
+			method:         http.MethodPut,
+			target:         "/api/configuration/update/00000000-0000-0000-0000-000000000000",
+			body:           `{"name":"timeout","value":"20"}`,
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "update without If-Match",
+			method:         http.MethodPut,
+			target:         "/api/configuration/update/00000000-0000-0000-0000-000000000000",
+			body:           `{"name":"timeout","value":"20"}`,
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "delete missing configuration",
+			method:         http.MethodDelete,
+			target:         "/api/configuration/name/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "delete existing configuration",
+			method:         http.MethodDelete,
+			target:         "/api/configuration/name/timeout",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := setupRouter(t)
+			status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"timeout","value":"10"}`)
+			require.Equal(t, http.StatusOK, status)
+
+			header := http.Header{}
+			if tc.ifMatch != "" {
+				header.Set("If-Match", tc.ifMatch)
+			}
+			if tc.ifNoneMatch != "" {
+				header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			status, _, response := doRequestWithHeader(t, router, tc.method, tc.target, tc.body, header)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedStatus, response.Status)
+		})
+	}
+}
+
+func TestNamespacedConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{"create namespace", http.MethodPost, "/api/namespaces", `{"name":"payments"}`, http.StatusCreated},
+		{"create duplicate namespace", http.MethodPost, "/api/namespaces", `{"name":"payments"}`, http.StatusConflict},
+		{"create invalid namespace", http.MethodPost, "/api/namespaces", `{"name":"Pay Ments"}`, http.StatusUnprocessableEntity},
+		{"create configuration", http.MethodPost, "/api/namespaces/payments/configuration/create", `{"name":"timeout","value":"10"}`, http.StatusOK},
+		{"get namespaced configuration", http.MethodGet, "/api/namespaces/payments/configuration/timeout", "", http.StatusOK},
+		{"default namespace is separate", http.MethodGet, "/api/configuration/timeout", "", http.StatusNotFound},
+		{"list unknown namespace", http.MethodGet, "/api/namespaces/missing/configuration", "", http.StatusNotFound},
+		{"create in unknown namespace", http.MethodPost, "/api/namespaces/missing/configuration/create", `{"name":"timeout","value":"10"}`, http.StatusNotFound},
+		{"delete non empty namespace", http.MethodDelete, "/api/namespaces/payments", "", http.StatusConflict},
+		{"delete default namespace", http.MethodDelete, "/api/namespaces/default", "", http.StatusConflict},
+		{"delete configuration", http.MethodDelete, "/api/namespaces/payments/configuration/name/timeout", "", http.StatusOK},
+		{"delete namespace", http.MethodDelete, "/api/namespaces/payments", "", http.StatusOK},
+		{"get deleted namespace", http.MethodGet, "/api/namespaces/payments", "", http.StatusNotFound},
+	}
+
+	for _, step := range steps {
+		status, _ := doRequest(t, router, step.method, step.target, step.body)
+		assert.Equal(t, step.expectedStatus, status, step.name)
+	}
+}
+
+func TestResolveConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"timeout","value":"10"}`)
+	require.Equal(t, http.StatusOK, status)
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/create?env=prod", `{"name":"timeout","value":"30"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	tests := []struct {
+		name           string
+		target         string
+		expectedStatus int
+		expectedValue  string
+		expectedSource string
+	}{
+		{"override", "/api/configuration/timeout/resolve?env=prod", http.StatusOK, "30", "prod"},
+		{"fallback to base", "/api/configuration/timeout/resolve?env=staging", http.StatusOK, "10", "base"},
+		{"base", "/api/configuration/timeout/resolve", http.StatusOK, "10", "base"},
+		{"missing", "/api/configuration/missing/resolve?env=prod", http.StatusNotFound, "", ""},
+	}
+
+	for _, tc := range tests {
+		status, response := doRequest(t, router, http.MethodGet, tc.target, "")
+		require.Equal(t, tc.expectedStatus, status, tc.name)
+		if tc.expectedStatus != http.StatusOK {
+			continue
+		}
+
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, tc.expectedValue, data["value"], tc.name)
+		assert.Equal(t, tc.expectedSource, data["source"], tc.name)
+	}
+}
+
+func TestTypedConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{"create int", http.MethodPost, "/api/configuration/create", `{"name":"retries","value":"3","type":"int"}`, http.StatusOK},
+		{"override inherits type", http.MethodPost, "/api/configuration/create?env=prod", `{"name":"retries","value":"many"}`, http.StatusUnprocessableEntity},
+		{"override with valid value", http.MethodPost, "/api/configuration/create?env=prod", `{"name":"retries","value":"5"}`, http.StatusOK},
+		{"upsert keeps type", http.MethodPut, "/api/configuration/retries", `{"value":"three"}`, http.StatusUnprocessableEntity},
+		{"upsert changes type", http.MethodPut, "/api/configuration/retries", `{"value":"three","type":"string"}`, http.StatusOK},
+	}
+
+	for _, step := range steps {
+		status, _, _ := doRequestWithHeader(t, router, step.method, step.target, step.body, anyVersion)
+		assert.Equal(t, step.expectedStatus, status, step.name)
+	}
+
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration/retries/resolve?env=prod", "")
+	require.Equal(t, http.StatusOK, status)
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, "5", data["value"])
+	assert.Equal(t, "int", data["type"])
+}
+
+func TestSchemaValidation(t *testing.T) {
+	router := setupRouter(t)
+
+	schema := `{"schema":{"type":"object","required":["host","port"],"properties":{"host":{"type":"string"},"port":{"type":"integer","minimum":1}}}}`
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{"invalid schema", http.MethodPut, "/api/schema/db", `{"schema":{"type":"nope"}}`, http.StatusUnprocessableEntity},
+		{"external reference", http.MethodPut, "/api/schema/db", `{"schema":{"$ref":"file:///etc/passwd"}}`, http.StatusUnprocessableEntity},
+		{"create schema", http.MethodPut, "/api/schema/db", schema, http.StatusCreated},
+		{"replace schema", http.MethodPut, "/api/schema/db", schema, http.StatusOK},
+		{"get schema", http.MethodGet, "/api/schema/db", "", http.StatusOK},
+		{"list schemas", http.MethodGet, "/api/schema", "", http.StatusOK},
+		{"valid value", http.MethodPost, "/api/configuration/create", `{"name":"db","value":"{\"host\":\"localhost\",\"port\":5432}","type":"json"}`, http.StatusOK},
+		{"not json", http.MethodPut, "/api/configuration/db", `{"value":"localhost"}`, http.StatusUnprocessableEntity},
+		{"override checked too", http.MethodPost, "/api/configuration/create?env=prod", `{"name":"db","value":"{\"host\":\"db\"}"}`, http.StatusUnprocessableEntity},
+		{"other names unaffected", http.MethodPost, "/api/configuration/create", `{"name":"cache","value":"anything"}`, http.StatusOK},
+		{"schema in unknown namespace", http.MethodPut, "/api/namespaces/missing/schema/db", schema, http.StatusNotFound},
+		{"delete schema", http.MethodDelete, "/api/schema/db", "", http.StatusOK},
+		{"delete missing schema", http.MethodDelete, "/api/schema/db", "", http.StatusNotFound},
+		{"no schema, no check", http.MethodPut, "/api/configuration/db", `{"value":"{}"}`, http.StatusOK},
+		// values of other types are checked as the JSON value of their type
+		{"string schema", http.MethodPut, "/api/schema/greeting", `{"schema":{"type":"string","minLength":3}}`, http.StatusCreated},
+		{"plain string", http.MethodPost, "/api/configuration/create", `{"name":"greeting","value":"hello"}`, http.StatusOK},
+		{"short string", http.MethodPut, "/api/configuration/greeting", `{"value":"hi"}`, http.StatusUnprocessableEntity},
+		{"number schema", http.MethodPut, "/api/schema/port", `{"schema":{"type":"integer","maximum":65535}}`, http.StatusCreated},
+		{"int value", http.MethodPost, "/api/configuration/create", `{"name":"port","value":"5432","type":"int"}`, http.StatusOK},
+		{"int out of range", http.MethodPut, "/api/configuration/port", `{"value":"70000"}`, http.StatusUnprocessableEntity},
+		{"digits as string", http.MethodPut, "/api/configuration/port", `{"value":"80","type":"string"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, step := range steps {
+		status, _, _ := doRequestWithHeader(t, router, step.method, step.target, step.body, anyVersion)
+		assert.Equal(t, step.expectedStatus, status, step.name)
+	}
+}
+
+func TestSchemaFieldErrors(t *testing.T) {
+	router := setupRouter(t)
+
+	schema := `{"schema":{"type":"object","required":["host"],"properties":{"port":{"type":"integer","minimum":1}}}}`
+	status, _ := doRequest(t, router, http.MethodPut, "/api/schema/db", schema)
+	require.Equal(t, http.StatusCreated, status)
+
+	status, response := doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"db","value":"{\"port\":0}","type":"json"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	fields := map[string]bool{}
+	for _, field := range response.Data.([]interface{}) {
+		fields[field.(map[string]interface{})["field"].(string)] = true
+	}
+	assert.Equal(t, map[string]bool{"": true, "/port": true}, fields)
+}
+
+func TestConfigurationHistory(t *testing.T) {
+	router := setupRouter(t)
+
+	for i, value := range []string{"10", "20", "30"} {
+		req := httptest.NewRequest(http.MethodPut, "/api/configuration/timeout", strings.NewReader(`{"value":"`+value+`"}`))
+		req.Header.Set("X-Actor", fmt.Sprintf("user-%d", i))
+		req.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Less(t, rec.Code, 300)
+	}
+
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration/timeout/history?limit=2", "")
+	require.Equal(t, http.StatusOK, status)
+	revisions := response.Data.([]interface{})
+	require.Len(t, revisions, 2)
+	newest := revisions[0].(map[string]interface{})
+	assert.Equal(t, float64(3), newest["revision"])
+	assert.Equal(t, "20", newest["oldvalue"])
+	assert.Equal(t, "30", newest["newvalue"])
+	assert.Equal(t, "user-2", newest["actor"])
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/timeout/history?limit=2&offset=2", "")
+	require.Equal(t, http.StatusOK, status)
+	revisions = response.Data.([]interface{})
+	require.Len(t, revisions, 1)
+	oldest := revisions[0].(map[string]interface{})
+	assert.Equal(t, "insert", oldest["action"])
+	assert.Nil(t, oldest["oldvalue"])
+
+	tests := []struct {
+		target         string
+		expectedStatus int
+	}{
+		{"/api/configuration/missing/history", http.StatusNotFound},
+		{"/api/configuration/timeout/history?env=prod", http.StatusNotFound},
+		{"/api/configuration/timeout/history?limit=abc", http.StatusBadRequest},
+		{"/api/configuration/timeout/history?limit=0", http.StatusUnprocessableEntity},
+		{"/api/configuration/timeout/history?offset=-1", http.StatusUnprocessableEntity},
+		{"/api/configuration/timeout/history?offset=10", http.StatusOK},
+	}
+	for _, tc := range tests {
+		status, _ := doRequest(t, router, http.MethodGet, tc.target, "")
+		assert.Equal(t, tc.expectedStatus, status, tc.target)
+	}
+}
+
+func TestRollbackConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+		expectedValue  string
+	}{
+		{"nothing to roll back", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"previous"}`, http.StatusNotFound, ""},
+		{"create", http.MethodPut, "/api/configuration/timeout", `{"value":"10","type":"int"}`, http.StatusCreated, ""},
+		{"no previous revision", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"previous"}`, http.StatusUnprocessableEntity, ""},
+		{"update", http.MethodPut, "/api/configuration/timeout", `{"value":"20"}`, http.StatusOK, ""},
+		{"bad update", http.MethodPut, "/api/configuration/timeout", `{"value":"30s","type":"duration"}`, http.StatusOK, ""},
+		{"previous", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"previous"}`, http.StatusOK, "20"},
+		{"by number", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":1}`, http.StatusOK, "10"},
+		{"undo the rollback", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"previous"}`, http.StatusOK, "20"},
+		{"unknown revision", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":42}`, http.StatusNotFound, ""},
+		{"missing revision", http.MethodPost, "/api/configuration/timeout/rollback", `{}`, http.StatusBadRequest, ""},
+		{"invalid revision", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"latest"}`, http.StatusBadRequest, ""},
+		{"negative revision", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":-1}`, http.StatusUnprocessableEntity, ""},
+		{"revision zero", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":0}`, http.StatusUnprocessableEntity, ""},
+		{"null revision", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":null}`, http.StatusBadRequest, ""},
+		{"misspelled field", http.MethodPost, "/api/configuration/timeout/rollback", `{"revison":1}`, http.StatusBadRequest, ""},
+		{"delete", http.MethodDelete, "/api/configuration/name/timeout", "", http.StatusOK, ""},
+		{"restore deleted", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":"previous"}`, http.StatusOK, "20"},
+		{"delete again", http.MethodDelete, "/api/configuration/name/timeout", "", http.StatusOK, ""},
+		{"revision without value", http.MethodPost, "/api/configuration/timeout/rollback", `{"revision":9}`, http.StatusUnprocessableEntity, ""},
+	}
+
+	for _, step := range steps {
+		status, _, response := doRequestWithHeader(t, router, step.method, step.target, step.body, anyVersion)
+		require.Equal(t, step.expectedStatus, status, step.name)
+		if step.expectedValue != "" {
+			data := response.Data.(map[string]interface{})
+			assert.Equal(t, step.expectedValue, data["value"], step.name)
+		}
+	}
+
+	// rollbacks restore the type too, and are part of the history
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration/timeout/history?limit=1&offset=1", "")
+	require.Equal(t, http.StatusOK, status)
+	revision := response.Data.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(8), revision["revision"])
+	assert.Equal(t, "int", revision["type"])
+}
+
+func TestConfigurationAsOf(t *testing.T) {
+	router := setupRouter(t)
+
+	status, _ := doRequest(t, router, http.MethodPut, "/api/configuration/timeout", `{"value":"10"}`)
+	require.Equal(t, http.StatusCreated, status)
+
+	time.Sleep(2 * time.Millisecond)
+	asOf := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(2 * time.Millisecond)
+
+	status, _, _ = doRequestWithHeader(t, router, http.MethodPut, "/api/configuration/timeout", `{"value":"20"}`, anyVersion)
+	require.Equal(t, http.StatusOK, status)
+
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration?asOf="+asOf, "")
+	require.Equal(t, http.StatusOK, status)
+	datas := response.Data.([]interface{})
+	require.Len(t, datas, 1)
+	assert.Equal(t, "10", datas[0].(map[string]interface{})["value"])
+
+	tests := []struct {
+		target         string
+		expectedStatus int
+	}{
+		{"/api/configuration?asOf=yesterday", http.StatusBadRequest},
+		{"/api/configuration?asOf=", http.StatusBadRequest},
+		{"/api/namespaces/missing/configuration?asOf=" + asOf, http.StatusNotFound},
+		{"/api/configuration?asOf=2000-01-01T00:00:00Z", http.StatusOK},
+	}
+	for _, tc := range tests {
+		status, _ := doRequest(t, router, http.MethodGet, tc.target, "")
+		assert.Equal(t, tc.expectedStatus, status, tc.target)
+	}
+}
+
+func TestListConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	for _, name := range []string{"db.host", "db.port", "db.user", "cache.ttl", "timeout"} {
+		status, _ := doRequest(t, router, http.MethodPut, "/api/configuration/"+name, `{"value":"10"}`)
+		require.Equal(t, http.StatusCreated, status)
+	}
+
+	names := func(response utils.WebResponse) []string {
+		result := []string{}
+		for _, data := range response.Data.([]interface{}) {
+			result = append(result, data.(map[string]interface{})["configname"].(string))
+		}
+		return result
+	}
+
+	// pages follow each other until the cursor runs out
+	listed := []string{}
+	target := "/api/configuration?limit=2"
+	for pages := 0; pages < 5; pages++ {
+		status, response := doRequest(t, router, http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, status)
+		listed = append(listed, names(response)...)
+		if response.NextCursor == "" {
+			break
+		}
+		target = "/api/configuration?limit=2&cursor=" + response.NextCursor
+	}
+	assert.Equal(t, []string{"cache.ttl", "db.host", "db.port", "db.user", "timeout"}, listed)
+
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration?prefix=db.&contains=o&sort=-name", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"db.port", "db.host"}, names(response))
+	assert.Empty(t, response.NextCursor)
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration?limit=1", "")
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, response.NextCursor)
+	cursor := response.NextCursor
+
+	tests := []struct {
+		target         string
+		expectedStatus int
+	}{
+		{"/api/configuration?limit=ten", http.StatusBadRequest},
+		{"/api/configuration?limit=0", http.StatusUnprocessableEntity},
+		{fmt.Sprintf("/api/configuration?limit=%d", services.MaxListLimit+1), http.StatusUnprocessableEntity},
+		{"/api/configuration?sort=value", http.StatusUnprocessableEntity},
+		{"/api/configuration?cursor=garbage", http.StatusUnprocessableEntity},
+		{"/api/configuration?sort=-name&cursor=" + cursor, http.StatusUnprocessableEntity},
+		{"/api/configuration?cursor=" + cursor, http.StatusOK},
+		{"/api/namespaces/missing/configuration", http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		status, _ := doRequest(t, router, http.MethodGet, tc.target, "")
+		assert.Equal(t, tc.expectedStatus, status, tc.target)
+	}
+}
+
+func TestConfigurationTree(t *testing.T) {
+	router := setupRouter(t)
+
+	bodies := []string{
+		`{"name":"payments.gateway.timeout","value":"10","type":"int"}`,
+		`{"name":"payments.gateway.retry.enabled","value":"true","type":"bool"}`,
+		`{"name":"payments.gateway.url","value":"https://pay.example.com","type":"url"}`,
+		`{"name":"payments.gateway","value":"stripe"}`,
+		`{"name":"payments.gatewayv2.timeout","value":"5"}`,
+		`{"name":"payments.currencies","value":"[\"eur\",\"usd\"]","type":"json"}`,
+	}
+	for _, body := range bodies {
+		status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", body)
+		require.Equal(t, http.StatusOK, status, body)
+	}
+	status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create?env=prod", `{"name":"payments.gateway.timeout","value":"30"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	dataOf := func(response utils.WebResponse) string {
+		data, err := json.Marshal(response.Data)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{
+			name:     "nested subtree",
+			target:   "/api/configuration/tree/payments.gateway",
+			expected: `{"":"stripe","timeout":10,"url":"https://pay.example.com","retry":{"enabled":true}}`,
+		},
+		{
+			name:     "environment overrides base",
+			target:   "/api/configuration/tree/payments.gateway.timeout?env=prod",
+			expected: `30`,
+		},
+		{
+			name:   "parent value moves under the empty key",
+			target: "/api/configuration/tree/payments",
+			expected: `{
+				"currencies":["eur","usd"],
+				"gateway":{"":"stripe","timeout":10,"url":"https://pay.example.com","retry":{"enabled":true}},
+				"gatewayv2":{"timeout":"5"}
+			}`,
+		},
+		{
+			name:   "flat",
+			target: "/api/configuration/tree/payments.gateway?format=flat&env=prod",
+			expected: `{
+				"payments.gateway":"stripe",
+				"payments.gateway.timeout":30,
+				"payments.gateway.url":"https://pay.example.com",
+				"payments.gateway.retry.enabled":true
+			}`,
+		},
+	}
+	for _, tc := range tests {
+		status, response := doRequest(t, router, http.MethodGet, tc.target, "")
+		require.Equal(t, http.StatusOK, status, tc.name)
+		assert.JSONEq(t, tc.expected, dataOf(response), tc.name)
+	}
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{"unknown path", http.MethodGet, "/api/configuration/tree/payments.missing", "", http.StatusNotFound},
+		{"empty segment", http.MethodGet, "/api/configuration/tree/payments..gateway", "", http.StatusUnprocessableEntity},
+		{"unknown format", http.MethodGet, "/api/configuration/tree/payments?format=xml", "", http.StatusBadRequest},
+		{"names may still have empty segments", http.MethodPost, "/api/configuration/create", `{"name":"legacy..key","value":"1"}`, http.StatusOK},
+		{"unknown namespace", http.MethodGet, "/api/namespaces/missing/configuration/tree/payments", "", http.StatusNotFound},
+		{"delete prod subtree", http.MethodDelete, "/api/configuration/tree/payments.gateway?env=prod", "", http.StatusOK},
+		{"delete missing subtree", http.MethodDelete, "/api/configuration/tree/payments.gateway?env=prod", "", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		status, _ := doRequest(t, router, step.method, step.target, step.body)
+		assert.Equal(t, step.expectedStatus, status, step.name)
+	}
+
+	status, response := doRequest(t, router, http.MethodDelete, "/api/configuration/tree/payments.gateway", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `["payments.gateway","payments.gateway.retry.enabled","payments.gateway.timeout","payments.gateway.url"]`, dataOf(response))
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/tree/payments?format=flat", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"payments.gatewayv2.timeout":"5","payments.currencies":["eur","usd"]}`, dataOf(response))
+
+	// tree is reserved below /configuration like create, a key named history
+	// has a tree of its own
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"history","value":"kept"}`)
+	require.Equal(t, http.StatusOK, status)
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/tree/history", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `"kept"`, dataOf(response))
+}
+
+func TestImportConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"DB_HOST","value":"old"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	resultsOf := func(response utils.WebResponse) map[string]string {
+		results := map[string]string{}
+		for _, data := range response.Data.([]interface{}) {
+			result := data.(map[string]interface{})
+			results[result["name"].(string)] = result["result"].(string)
+		}
+		return results
+	}
+
+	env := "DB_HOST=localhost\nDB_PORT=5432\n"
+	status, response := doRequest(t, router, http.MethodPost, "/api/configuration/import?format=env", env)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]string{"DB_HOST": "skipped", "DB_PORT": "created"}, resultsOf(response))
+
+	status, response = doRequest(t, router, http.MethodPost, "/api/configuration/import?format=env&mode=overwrite", env)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]string{"DB_HOST": "updated", "DB_PORT": "skipped"}, resultsOf(response))
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/DB_HOST", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "localhost", response.Data.(map[string]interface{})["value"])
+
+	// a conflict fails the whole import
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/import?format=env&mode=fail", "DB_USER=admin\nDB_PORT=1\n")
+	assert.Equal(t, http.StatusConflict, status)
+	status, _ = doRequest(t, router, http.MethodGet, "/api/configuration/DB_USER", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// multipart files are read with the format of their extension
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "payments.yaml")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("payments:\n  timeout: 10\n  enabled: true\n"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	header := http.Header{"Content-Type": {form.FormDataContentType()}}
+	status, _, response = doRequestWithHeader(t, router, http.MethodPost, "/api/configuration/import?env=prod", body.String(), header)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]string{"payments.enabled": "created", "payments.timeout": "created"}, resultsOf(response))
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/payments.timeout?env=prod", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "int", response.Data.(map[string]interface{})["type"])
+
+	header = http.Header{"Content-Type": {"application/json"}}
+	status, _, response = doRequestWithHeader(t, router, http.MethodPost, "/api/configuration/import", `{"feature":{"flag":true}}`, header)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]string{"feature.flag": "created"}, resultsOf(response))
+
+	tests := []struct {
+		name           string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{"unknown format", "/api/configuration/import", "A=b", http.StatusBadRequest},
+		{"unsupported format", "/api/configuration/import?format=ini", "A=b", http.StatusUnprocessableEntity},
+		{"unknown mode", "/api/configuration/import?format=env&mode=merge", "A=b", http.StatusUnprocessableEntity},
+		{"malformed file", "/api/configuration/import?format=json", "{", http.StatusUnprocessableEntity},
+		{"empty file", "/api/configuration/import?format=env", "# nothing", http.StatusUnprocessableEntity},
+		{"untyped base value", "/api/configuration/import?format=properties&mode=overwrite", "payments.timeout=soon", http.StatusOK},
+		{"invalid inherited value", "/api/configuration/import?format=properties&mode=overwrite&env=prod", "payments.timeout=soon", http.StatusUnprocessableEntity},
+		{"unknown namespace", "/api/namespaces/missing/configuration/import?format=env", "A=b", http.StatusNotFound},
+		{"body too large", "/api/configuration/import?format=env", "A=" + strings.Repeat("b", maxImportSize), http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		status, _ := doRequest(t, router, http.MethodPost, tc.target, tc.body)
+		assert.Equal(t, tc.expectedStatus, status, tc.name)
+	}
+
+	// multipart files have the limit of raw bodies
+	for _, size := range []int{maxImportSize + 1, maxImportSize + maxFormOverhead} {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", "large.env")
+		require.NoError(t, err)
+		_, err = part.Write([]byte("A=" + strings.Repeat("b", size-2)))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		header := http.Header{"Content-Type": {form.FormDataContentType()}}
+		status, _, _ := doRequestWithHeader(t, router, http.MethodPost, "/api/configuration/import", body.String(), header)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status, size)
+	}
+}
+
+func TestExportConfiguration(t *testing.T) {
+	router := setupRouter(t)
+
+	bodies := []string{
+		`{"name":"payments.timeout","value":"10","type":"int"}`,
+		`{"name":"payments.url","value":"https://pay.example.com","type":"url"}`,
+		`{"name":"search.url","value":"https://search.example.com","type":"url"}`,
+	}
+	for _, body := range bodies {
+		status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", body)
+		require.Equal(t, http.StatusOK, status, body)
+	}
+
+	tests := []struct {
+		target              string
+		expectedType        string
+		expectedDisposition string
+		expectedBody        string
+	}{
+		{
+			target:              "/api/configuration/export?format=env&prefix=payments.",
+			expectedType:        "text/plain; charset=utf-8",
+			expectedDisposition: `attachment; filename="default-base.env"`,
+			expectedBody:        "payments.timeout=\"10\"\npayments.url=\"https://pay.example.com\"\n",
+		},
+		{
+			target:              "/api/configuration/export?format=json&prefix=payments.",
+			expectedType:        "application/json",
+			expectedDisposition: `attachment; filename="default-base.json"`,
+			expectedBody:        "{\n  \"payments\": {\n    \"timeout\": 10,\n    \"url\": \"https://pay.example.com\"\n  }\n}\n",
+		},
+		{
+			target:              "/api/configuration/export?format=toml",
+			expectedType:        "application/toml",
+			expectedDisposition: `attachment; filename="default-base.toml"`,
+			expectedBody:        "[payments]\ntimeout = 10\nurl = \"https://pay.example.com\"\n\n[search]\nurl = \"https://search.example.com\"\n",
+		},
+		{
+			target:              "/api/configuration/export?format=properties&prefix=search",
+			expectedType:        "text/x-java-properties",
+			expectedDisposition: `attachment; filename="default-base.properties"`,
+			expectedBody:        "search.url=https\\://search.example.com\n",
+		},
+		{
+			target:              "/api/configuration/export?format=yaml&env=prod",
+			expectedType:        "application/yaml",
+			expectedDisposition: `attachment; filename="default-prod.yaml"`,
+			expectedBody:        "",
+		},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, tc.target)
+		assert.Equal(t, tc.expectedType, rec.Header().Get("Content-Type"), tc.target)
+		assert.Equal(t, tc.expectedDisposition, rec.Header().Get("Content-Disposition"), tc.target)
+		assert.Equal(t, tc.expectedBody, rec.Body.String(), tc.target)
+	}
+
+	steps := []struct {
+		target         string
+		expectedStatus int
+	}{
+		{"/api/configuration/export", http.StatusBadRequest},
+		{"/api/configuration/export?format=ini", http.StatusUnprocessableEntity},
+		{"/api/configuration/export?format=env&env=Pro%22d", http.StatusUnprocessableEntity},
+		{"/api/namespaces/missing/configuration/export?format=env", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		status, _ := doRequest(t, router, http.MethodGet, step.target, "")
+		assert.Equal(t, step.expectedStatus, status, step.target)
+	}
+
+	// a json object and the children of its name come back apart
+	for _, body := range []string{
+		`{"name":"obj","value":"{\"x\":1}","type":"json"}`,
+		`{"name":"obj.y","value":"2","type":"int"}`,
+	} {
+		status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", body)
+		require.Equal(t, http.StatusOK, status, body)
+	}
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration/tree/obj", "")
+	require.Equal(t, http.StatusOK, status)
+	tree, err := json.Marshal(response.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"":{"x":1},"y":2}`, string(tree))
+
+	for _, format := range []string{"json", "yaml"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/configuration/export?format="+format+"&prefix=obj", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, format)
+
+		status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/import?format="+format+"&env=copy"+format, rec.Body.String())
+		require.Equal(t, http.StatusOK, status, format)
+
+		status, response = doRequest(t, router, http.MethodGet, "/api/configuration/obj?env=copy"+format, "")
+		require.Equal(t, http.StatusOK, status, format)
+		assert.Equal(t, `{"x":1}`, response.Data.(map[string]interface{})["value"], format)
+		assert.Equal(t, "json", response.Data.(map[string]interface{})["type"], format)
+		status, _ = doRequest(t, router, http.MethodGet, "/api/configuration/obj.x?env=copy"+format, "")
+		assert.Equal(t, http.StatusNotFound, status, format)
+	}
+
+	// exports read past a single page of keys
+	var env strings.Builder
+	for i := 0; i <= services.MaxListLimit; i++ {
+		fmt.Fprintf(&env, "BULK_%04d=%d\n", i, i)
+	}
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/import?format=env&env=bulk", env.String())
+	require.Equal(t, http.StatusOK, status)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configuration/export?format=env&env=bulk&prefix=BULK_", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+	assert.Len(t, lines, services.MaxListLimit+1)
+	assert.Equal(t, fmt.Sprintf(`BULK_%04d="%d"`, services.MaxListLimit, services.MaxListLimit), lines[len(lines)-1])
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	router := setupRouter(t)
+
+	status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", `{"name":"timeout","value":"10"}`)
+	require.Equal(t, http.StatusOK, status)
+
+	status, header, response := doRequestWithHeader(t, router, http.MethodGet, "/api/configuration/timeout", "", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `"1"`, header.Get("ETag"))
+	id := response.Data.(map[string]interface{})["id"].(string)
+	update := "/api/configuration/update/" + id
+
+	ifMatch := func(value string) http.Header {
+		return http.Header{"If-Match": []string{value}}
+	}
+
+	steps := []struct {
+		name           string
+		method         string
+		target         string
+		body           string
+		header         http.Header
+		expectedStatus int
+	}{
+		{"first writer wins", http.MethodPut, update, `{"name":"timeout","value":"20"}`, ifMatch(`"1"`), http.StatusOK},
+		{"second writer is stale", http.MethodPut, update, `{"name":"timeout","value":"30"}`, ifMatch(`"1"`), http.StatusPreconditionFailed},
+		{"second writer retries", http.MethodPut, update, `{"name":"timeout","value":"30"}`, ifMatch(`"2"`), http.StatusOK},
+		{"missing If-Match", http.MethodPut, update, `{"name":"timeout","value":"40"}`, nil, http.StatusPreconditionRequired},
+		{"malformed If-Match", http.MethodPut, update, `{"name":"timeout","value":"40"}`, ifMatch("3"), http.StatusPreconditionFailed},
+		{"weak If-Match", http.MethodPut, update, `{"name":"timeout","value":"40"}`, ifMatch(`W/"3"`), http.StatusPreconditionFailed},
+		{"any version", http.MethodPut, update, `{"name":"timeout","value":"40"}`, ifMatch("*"), http.StatusOK},
+		{"upsert without If-Match", http.MethodPut, "/api/configuration/timeout", `{"value":"50"}`, nil, http.StatusPreconditionRequired},
+		{"upsert of any version", http.MethodPut, "/api/configuration/timeout", `{"value":"50"}`, ifMatch("*"), http.StatusOK},
+		{"stale upsert", http.MethodPut, "/api/configuration/timeout", `{"value":"60"}`, ifMatch(`"4"`), http.StatusPreconditionFailed},
+		{"upsert", http.MethodPut, "/api/configuration/timeout", `{"value":"60"}`, ifMatch(`"5"`), http.StatusOK},
+		{"upsert of a missing name with If-Match", http.MethodPut, "/api/configuration/retries", `{"value":"3"}`, ifMatch(`"1"`), http.StatusPreconditionFailed},
+	}
+
+	for _, step := range steps {
+		status, _, _ := doRequestWithHeader(t, router, step.method, step.target, step.body, step.header)
+		assert.Equal(t, step.expectedStatus, status, step.name)
+	}
+
+	status, header, response = doRequestWithHeader(t, router, http.MethodGet, "/api/configuration/timeout", "", nil)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `"6"`, header.Get("ETag"))
+	assert.Equal(t, "60", response.Data.(map[string]interface{})["value"])
+}
+
+func TestApplyBatch(t *testing.T) {
+	router := setupRouter(t)
+
+	for _, body := range []string{`{"name":"timeout","value":"10","type":"int"}`, `{"name":"retries","value":"3"}`} {
+		status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/create", body)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"empty batch", `{"operations":[]}`, http.StatusUnprocessableEntity},
+		{"unknown operation", `{"operations":[{"op":"create","name":"a","value":"1"},{"op":"rename","name":"timeout"}]}`, http.StatusUnprocessableEntity},
+		{"invalid value rolls back", `{"operations":[{"op":"create","name":"a","value":"1"},{"op":"update","name":"timeout","value":"abc","version":1}]}`, http.StatusUnprocessableEntity},
+		{"duplicate rolls back", `{"operations":[{"op":"delete","name":"retries"},{"op":"create","name":"timeout","value":"1"}]}`, http.StatusConflict},
+		{"missing key rolls back", `{"operations":[{"op":"update","name":"timeout","value":"20","any_version":true},{"op":"delete","name":"missing"}]}`, http.StatusNotFound},
+		{"update without version", `{"operations":[{"op":"update","name":"timeout","value":"20"}]}`, http.StatusUnprocessableEntity},
+		{"stale version rolls back", `{"operations":[{"op":"create","name":"a","value":"1"},{"op":"update","name":"timeout","value":"20","version":7}]}`, http.StatusPreconditionFailed},
+		{"invalid json", `{"operations":`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		status, _ := doRequest(t, router, http.MethodPost, "/api/configuration/batch", tc.body)
+		assert.Equal(t, tc.expectedStatus, status, tc.name)
+	}
+
+	// nothing from the failed batches was kept
+	status, response := doRequest(t, router, http.MethodGet, "/api/configuration", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, response.Data.([]interface{}), 2)
+
+	body := `{"operations":[
+		{"op":"update","name":"timeout","value":"20","version":1},
+		{"op":"delete","name":"retries"},
+		{"op":"create","name":"feature","value":"true","type":"bool"},
+		{"op":"create","name":"feature","environment":"prod","value":"false"}
+	]}`
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/batch", body)
+	require.Equal(t, http.StatusOK, status)
+
+	// any_version opts out of the version check
+	status, _ = doRequest(t, router, http.MethodPost, "/api/configuration/batch", `{"operations":[{"op":"update","name":"timeout","value":"30","any_version":true}]}`)
+	require.Equal(t, http.StatusOK, status)
+
+	expected := map[string]string{"timeout": "30", "feature": "true"}
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration", "")
+	require.Equal(t, http.StatusOK, status)
+	datas := response.Data.([]interface{})
+	require.Len(t, datas, len(expected))
+	for _, data := range datas {
+		configuration := data.(map[string]interface{})
+		assert.Equal(t, expected[configuration["configname"].(string)], configuration["value"])
+	}
+
+	status, response = doRequest(t, router, http.MethodGet, "/api/configuration/feature?env=prod", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "bool", response.Data.(map[string]interface{})["type"])
+}
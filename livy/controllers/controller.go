@@ -3,10 +3,13 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"livy/livy/models"
 	"livy/livy/services"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -25,14 +28,125 @@ func NewController(ctx context.Context, svc *services.LivySvc) *LivyController{
 func (h *LivyController) registerHandler() *mux.Router {
 	router := mux.NewRouter()
 
-	router.HandleFunc("/api/configuration", h.getAllConfiguration).Methods(http.MethodGet)
-	router.HandleFunc("/api/configuration/{configname}", h.getConfiguration).Methods(http.MethodGet)
-	router.HandleFunc("/api/configuration/update/{id}", h.updateConfiguration).Methods(http.MethodPut)
-	router.HandleFunc("/api/configuration/create", h.createConfiguration).Methods(http.MethodPost)
-	
+	router.HandleFunc("/api/namespaces", h.getAllNamespace).Methods(http.MethodGet)
+	router.HandleFunc("/api/namespaces", h.createNamespace).Methods(http.MethodPost)
+	router.HandleFunc("/api/namespaces/{ns}", h.getNamespace).Methods(http.MethodGet)
+	router.HandleFunc("/api/namespaces/{ns}", h.deleteNamespace).Methods(http.MethodDelete)
+
+	// configuration routes work on /api/namespaces/{ns}, /api is the default namespace
+	h.registerConfigurationHandler(router.PathPrefix("/api/namespaces/{ns}").Subrouter())
+	h.registerConfigurationHandler(router.PathPrefix("/api").Subrouter())
+
 	return router
 }
 
+func (h *LivyController) registerConfigurationHandler(router *mux.Router) {
+	router.HandleFunc("/configuration", h.getAllConfiguration).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/export", h.exportConfiguration).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/tree/{path}", h.getConfigurationTree).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/tree/{path}", h.deleteConfigurationTree).Methods(http.MethodDelete)
+	router.HandleFunc("/configuration/{configname}", h.getConfiguration).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/{configname}/resolve", h.resolveConfiguration).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/{configname}/history", h.getConfigurationHistory).Methods(http.MethodGet)
+	router.HandleFunc("/configuration/{configname}/rollback", h.rollbackConfiguration).Methods(http.MethodPost)
+	router.HandleFunc("/configuration/update/{id}", h.updateConfiguration).Methods(http.MethodPut)
+	router.HandleFunc("/configuration/create", h.createConfiguration).Methods(http.MethodPost)
+	router.HandleFunc("/configuration/batch", h.applyBatch).Methods(http.MethodPost)
+	router.HandleFunc("/configuration/import", h.importConfiguration).Methods(http.MethodPost)
+	router.HandleFunc("/configuration/{configname}", h.upsertConfiguration).Methods(http.MethodPut)
+	router.HandleFunc("/configuration/name/{configname}", h.deleteConfigurationByName).Methods(http.MethodDelete)
+	router.HandleFunc("/configuration/{id}", h.deleteConfiguration).Methods(http.MethodDelete)
+
+	router.HandleFunc("/schema", h.getAllSchema).Methods(http.MethodGet)
+	router.HandleFunc("/schema/{configname}", h.getSchema).Methods(http.MethodGet)
+	router.HandleFunc("/schema/{configname}", h.upsertSchema).Methods(http.MethodPut)
+	router.HandleFunc("/schema/{configname}", h.deleteSchema).Methods(http.MethodDelete)
+}
+
+// namespaceOf returns the namespace of the request, routes without one use the default namespace
+func namespaceOf(r *http.Request) string {
+	namespace, ok := mux.Vars(r)["ns"]
+	if !ok {
+		return models.DefaultNamespace
+	}
+
+	return namespace
+}
+
+// environmentOf returns the env query parameter, defaulting to the base environment
+func environmentOf(r *http.Request) string {
+	environment := r.URL.Query().Get("env")
+	if environment == "" {
+		return models.BaseEnvironment
+	}
+
+	return environment
+}
+
+// etag formats a configuration version as a strong entity tag
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchOf returns the version expected by the If-Match header and whether
+// the header was sent. "*" matches any version and is returned as 0, a tag
+// that isn't a version can never match.
+func ifMatchOf(r *http.Request) (int, bool, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false, nil
+	}
+	if value == "*" {
+		return 0, true, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || version < 1 || value != etag(version) {
+		return 0, true, fmt.Errorf("if-match %s: %w", value, services.ErrStaleVersion)
+	}
+
+	return version, true, nil
+}
+
+// defaultPageLimit is the page size used when the request doesn't set limit
+const defaultPageLimit = 20
+
+// defaultListLimit is the number of configurations listed when the request
+// doesn't set limit
+const defaultListLimit = 100
+
+// pageOf returns the limit and offset query parameters
+func pageOf(r *http.Request) (int, int, error) {
+	limit, offset := defaultPageLimit, 0
+
+	var err error
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if value := r.URL.Query().Get("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// svcFor returns the service attributing changes to the actor of the request,
+// named by the X-Actor header
+func (h *LivyController) svcFor(r *http.Request) *services.LivySvc {
+	actor := r.Header.Get("X-Actor")
+	if actor == "" {
+		return h.svc
+	}
+
+	return h.svc.WithActor(actor)
+}
+
 func (c *LivyController) Start() error {
 	listenAddr := os.Getenv("API_URL")
 	listenPort := os.Getenv("API_PORT")
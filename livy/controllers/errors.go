@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	"livy/livy/services"
+	"livy/utils"
+	"log"
+	"net/http"
+)
+
+// writeError maps domain errors to their HTTP status. Anything unexpected is
+// logged and reported as a 500 without leaking the underlying message. Schema
+// violations carry the list of offending fields as data.
+func writeError(w http.ResponseWriter, err error) {
+	var validationErr *services.ValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, err.Error(), validationErr.Fields)
+	case errors.Is(err, services.ErrNotFound):
+		utils.WriteJSON(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, services.ErrAlreadyExists), errors.Is(err, services.ErrConflict):
+		utils.WriteJSON(w, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, services.ErrStaleVersion):
+		utils.WriteJSON(w, http.StatusPreconditionFailed, err.Error(), nil)
+	case errors.Is(err, services.ErrValidation):
+		utils.WriteJSON(w, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+		log.Println(err)
+		utils.WriteJSON(w, http.StatusInternalServerError, "Internal Server Error", nil)
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"fmt"
+	"livy/livy/formats"
+	"livy/utils"
+	"net/http"
+)
+
+// exportConfiguration writes the configurations of the environment as a file
+// in the format query parameter, filtered by the prefix one
+func (h *LivyController) exportConfiguration(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, "Set format To env, yaml, json, toml Or properties", nil)
+		return
+	}
+
+	namespace, environment := namespaceOf(r), environmentOf(r)
+	data, err := h.svc.ExportConfiguration(namespace, environment, query.Get("prefix"), format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// the service checked the namespace and environment are plain names
+	filename := fmt.Sprintf("%s-%s.%s", namespace, environment, format)
+	w.Header().Set("Content-Type", formats.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"livy/livy/formats"
+	"livy/utils"
+	"mime"
+	"net/http"
+)
+
+// maxImportSize is the largest file an import reads, multipart or raw. Files
+// are parsed in memory.
+const maxImportSize = 32 << 20
+
+// maxFormOverhead is what a multipart form may add to its file: boundaries,
+// part headers and other fields
+const maxFormOverhead = 1 << 20
+
+// importTooLarge is the message of the 413 returned past maxImportSize
+const importTooLarge = "Import Too Large, Files Are Limited To 32 MiB"
+
+// importConfiguration imports the keys of a file sent as the file field of a
+// multipart form or as the raw body. The format query parameter defaults to
+// the extension of the file or the media type of the body.
+func (h *LivyController) importConfiguration(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+
+	var data []byte
+	var tooLarge *http.MaxBytesError
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+maxFormOverhead)
+		err := r.ParseMultipartForm(maxImportSize)
+		if errors.As(err, &tooLarge) {
+			utils.WriteJSON(w, http.StatusRequestEntityTooLarge, importTooLarge, nil)
+			return
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, "Invalid Multipart Form", nil)
+			return
+		}
+
+		defer r.MultipartForm.RemoveAll()
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, "Missing file Field", nil)
+			return
+		}
+
+		defer file.Close()
+
+		if header.Size > maxImportSize {
+			utils.WriteJSON(w, http.StatusRequestEntityTooLarge, importTooLarge, nil)
+			return
+		}
+
+		if format == "" {
+			format = formats.FormatOf(header.Filename)
+		}
+		data, err = io.ReadAll(file)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+			return
+		}
+	} else {
+		var err error
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		data, err = io.ReadAll(r.Body)
+		if errors.As(err, &tooLarge) {
+			utils.WriteJSON(w, http.StatusRequestEntityTooLarge, importTooLarge, nil)
+			return
+		}
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+			return
+		}
+
+		defer r.Body.Close()
+
+		if format == "" {
+			format = formats.FormatOfMediaType(mediaType)
+		}
+	}
+
+	if format == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, "Unknown Format, Set format To env, yaml, json Or properties", nil)
+		return
+	}
+
+	results, err := h.svcFor(r).ImportConfiguration(namespaceOf(r), environmentOf(r), format, data, query.Get("mode"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Configuration Imported Successfully", results)
+}
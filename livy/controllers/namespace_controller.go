@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"livy/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type namespacePayload struct {
+	Name string `json:"name"`
+}
+
+func (h *LivyController) getAllNamespace(w http.ResponseWriter, r *http.Request) {
+	datas, err := h.svc.GetAllNamespace()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) getNamespace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["ns"]
+
+	datas, err := h.svc.GetNamespace(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) createNamespace(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+		return
+	}
+
+	defer r.Body.Close()
+
+	payload := namespacePayload{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
+
+	err = h.svc.InsertNamespace(payload.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, "Namespace Created Successfully", nil)
+}
+
+func (h *LivyController) deleteNamespace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["ns"]
+
+	err := h.svc.DeleteNamespace(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Namespace Deleted Successfully", nil)
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"livy/livy/models"
+	"livy/utils"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type schemaPayload struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+func (h *LivyController) getAllSchema(w http.ResponseWriter, r *http.Request) {
+	datas, err := h.svc.GetAllSchema(namespaceOf(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) getSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	datas, err := h.svc.GetSchema(namespaceOf(r), configname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "", datas)
+}
+
+func (h *LivyController) upsertSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid Body Request", nil)
+		return
+	}
+
+	defer r.Body.Close()
+
+	payload := schemaPayload{}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, "Invalid JSON Format", nil)
+		return
+	}
+
+	created, err := h.svc.UpsertSchema(models.Schema{
+		Namespace:  namespaceOf(r),
+		ConfigName: configname,
+		Schema:     payload.Schema,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if created {
+		utils.WriteJSON(w, http.StatusCreated, "Schema Created Successfully", nil)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Schema Updated Successfully", nil)
+}
+
+func (h *LivyController) deleteSchema(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	configname := vars["configname"]
+
+	err := h.svc.DeleteSchema(namespaceOf(r), configname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, "Schema Deleted Successfully", nil)
+}
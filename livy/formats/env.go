@@ -0,0 +1,137 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseEnv reads KEY=VALUE lines. Values may be single quoted, kept as they
+// are, or double quoted, with \n, \t, \" and \\ escapes. Unquoted values end
+// at " #", blank lines and lines starting with # are skipped, an export
+// prefix is ignored.
+func parseEnv(data string) ([]Entry, error) {
+	entries := []Entry{}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		number := i + 1
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", number)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// double quoted values may span lines
+			for !closedDoubleQuote(value) && i+1 < len(lines) {
+				i++
+				value += "\n" + lines[i]
+			}
+			unquoted, err := unquoteEnv(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quote", number)
+			}
+			value = value[1 : end+1]
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = value[:comment]
+			}
+			value = strings.TrimSpace(value)
+		}
+
+		entries = append(entries, Entry{Name: name, Value: value})
+	}
+
+	return entries, nil
+}
+
+// closedDoubleQuote reports whether value, starting with a double quote,
+// holds its unescaped closing quote
+func closedDoubleQuote(value string) bool {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+
+	return false
+}
+
+func unquoteEnv(value string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case '"':
+			rest := strings.TrimSpace(value[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected %q after the closing quote", rest)
+			}
+			return b.String(), nil
+		case '\\':
+			if i+1 == len(value) {
+				break
+			}
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(value[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", fmt.Errorf("unterminated double quote")
+}
+
+// envEscaper escapes double quoted values, $ and ` too so that shells
+// sourcing the file don't expand them
+var envEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"$", `\$`,
+	"`", "\\`",
+)
+
+// renderEnv writes KEY="VALUE" lines, names that parseEnv would read
+// differently are refused
+func renderEnv(entries []Entry) ([]byte, error) {
+	var b bytes.Buffer
+	for _, entry := range entries {
+		if entry.Name == "" || strings.ContainsAny(entry.Name, "= \t\r\n\"'") || strings.HasPrefix(entry.Name, "#") {
+			return nil, fmt.Errorf("key %q can't be written to a .env file", entry.Name)
+		}
+
+		fmt.Fprintf(&b, "%s=\"%s\"\n", entry.Name, envEscaper.Replace(entry.Value))
+	}
+
+	return b.Bytes(), nil
+}
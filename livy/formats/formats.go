@@ -0,0 +1,147 @@
+// Package formats reads and writes configuration files: .env, YAML, JSON
+// and Java properties. TOML is only written.
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Formats of configuration files
+const (
+	Env        = "env"
+	YAML       = "yaml"
+	JSON       = "json"
+	Properties = "properties"
+	TOML       = "toml"
+)
+
+// ErrUnknownFormat is returned for formats this package doesn't read or write
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Entry is a key read from a file. Type is empty when the format doesn't
+// tell, nested YAML and JSON keys are joined with dots.
+type Entry struct {
+	Name  string
+	Value string
+	Type  string
+}
+
+// FormatOf returns the format of a file named filename, empty when the
+// extension is unknown
+func FormatOf(filename string) string {
+	base := path.Base(filename)
+	switch {
+	case base == ".env", strings.HasSuffix(base, ".env"), strings.HasPrefix(base, ".env."):
+		return Env
+	}
+
+	switch path.Ext(base) {
+	case ".yaml", ".yml":
+		return YAML
+	case ".json":
+		return JSON
+	case ".properties":
+		return Properties
+	}
+
+	return ""
+}
+
+// FormatOfMediaType returns the format of a body sent as mediaType, empty
+// when the media type is unknown
+func FormatOfMediaType(mediaType string) string {
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return YAML
+	case "application/json":
+		return JSON
+	case "text/x-java-properties":
+		return Properties
+	}
+
+	return ""
+}
+
+// Parse reads the keys of data in format. A key set twice keeps its last
+// value, entries are in the order of their last occurrence.
+func Parse(format string, data []byte) ([]Entry, error) {
+	var entries []Entry
+	var err error
+
+	switch format {
+	case Env:
+		entries, err = parseEnv(string(data))
+	case YAML:
+		entries, err = parseYAML(data)
+	case JSON:
+		entries, err = parseJSON(data)
+	case Properties:
+		entries, err = parseProperties(string(data))
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	last := map[string]int{}
+	for i, entry := range entries {
+		last[entry.Name] = i
+	}
+
+	unique := []Entry{}
+	for i, entry := range entries {
+		if last[entry.Name] == i {
+			unique = append(unique, entry)
+		}
+	}
+
+	return unique, nil
+}
+
+// ContentType returns the media type of files in format
+func ContentType(format string) string {
+	switch format {
+	case YAML:
+		return "application/yaml"
+	case JSON:
+		return "application/json"
+	case TOML:
+		return "application/toml"
+	case Properties:
+		return "text/x-java-properties"
+	}
+
+	return "text/plain; charset=utf-8"
+}
+
+// Render writes entries in format, sorted by name. YAML, JSON and TOML nest
+// dotted names and write values as their type, a name that is also the
+// parent of others keeps its value under the empty key. Json objects are
+// written as strings, read back as keys they would be lost. TOML has no null,
+// values holding one are left out with a comment naming them.
+func Render(format string, entries []Entry) ([]byte, error) {
+	sorted := append([]Entry{}, entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	switch format {
+	case Env:
+		return renderEnv(sorted)
+	case Properties:
+		return renderProperties(sorted), nil
+	case YAML:
+		return renderYAML(Nest(documentEntries(sorted)))
+	case JSON:
+		return renderJSON(Nest(documentEntries(sorted)))
+	case TOML:
+		return renderTOML(Nest(documentEntries(sorted)))
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
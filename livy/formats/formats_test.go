@@ -0,0 +1,377 @@
+package formats
+
+import (
+	"livy/livy/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		data          string
+		expected      []Entry
+		expectedError bool
+	}{
+		{
+			name:   "env",
+			format: Env,
+			data: "# database\n" +
+				"DB_HOST=localhost # local only\n" +
+				"export DB_PORT = 5432\n" +
+				"\n" +
+				"GREETING=\"hello\\n\\\"world\\\"\"\n" +
+				"RAW='a #b \\n'\n" +
+				"MULTI=\"one\ntwo\"\n" +
+				"EMPTY=\n" +
+				"DB_HOST=db\n",
+			expected: []Entry{
+				{Name: "DB_PORT", Value: "5432"},
+				{Name: "GREETING", Value: "hello\n\"world\""},
+				{Name: "RAW", Value: `a #b \n`},
+				{Name: "MULTI", Value: "one\ntwo"},
+				{Name: "EMPTY", Value: ""},
+				{Name: "DB_HOST", Value: "db"},
+			},
+		},
+		{
+			name:          "env without equal sign",
+			format:        Env,
+			data:          "DB_HOST\n",
+			expectedError: true,
+		},
+		{
+			name:          "env unterminated quote",
+			format:        Env,
+			data:          "DB_HOST=\"localhost\n",
+			expectedError: true,
+		},
+		{
+			name:   "properties",
+			format: Properties,
+			data: "# comment\n" +
+				"! comment\n" +
+				"db.host=localhost\n" +
+				"db.port : 5432\n" +
+				"db.user admin\n" +
+				"key\\ with\\=escapes = value\\tindented\n" +
+				"greeting = caf\\u00e9\n" +
+				"list = one, \\\n" +
+				"       two\n" +
+				"empty\n",
+			expected: []Entry{
+				{Name: "db.host", Value: "localhost"},
+				{Name: "db.port", Value: "5432"},
+				{Name: "db.user", Value: "admin"},
+				{Name: "key with=escapes", Value: "value\tindented"},
+				{Name: "greeting", Value: "café"},
+				{Name: "list", Value: "one, two"},
+				{Name: "empty", Value: ""},
+			},
+		},
+		{
+			name:          "properties malformed unicode escape",
+			format:        Properties,
+			data:          "key=\\u12\n",
+			expectedError: true,
+		},
+		{
+			name:   "yaml",
+			format: YAML,
+			data: "payments:\n" +
+				"  gateway:\n" +
+				"    timeout: 10\n" +
+				"    ratio: 0.5\n" +
+				"    enabled: true\n" +
+				"    url: \"https://pay.example.com\"\n" +
+				"  currencies: [eur, usd]\n" +
+				"name: '42'\n",
+			expected: []Entry{
+				{Name: "name", Value: "42"},
+				{Name: "payments.currencies", Value: `["eur","usd"]`, Type: models.TypeJSON},
+				{Name: "payments.gateway.enabled", Value: "true", Type: models.TypeBool},
+				{Name: "payments.gateway.ratio", Value: "0.5", Type: models.TypeFloat},
+				{Name: "payments.gateway.timeout", Value: "10", Type: models.TypeInt},
+				{Name: "payments.gateway.url", Value: "https://pay.example.com"},
+			},
+		},
+		{
+			name:          "yaml null",
+			format:        YAML,
+			data:          "key: null\n",
+			expectedError: true,
+		},
+		{
+			name:          "yaml not a mapping",
+			format:        YAML,
+			data:          "- a\n- b\n",
+			expectedError: true,
+		},
+		{
+			name:   "json",
+			format: JSON,
+			data:   `{"payments":{"timeout":10,"ratio":1.5e3,"enabled":false,"tags":[1,{"a":"b"}]},"name":"api"}`,
+			expected: []Entry{
+				{Name: "name", Value: "api"},
+				{Name: "payments.enabled", Value: "false", Type: models.TypeBool},
+				{Name: "payments.ratio", Value: "1.5e3", Type: models.TypeFloat},
+				{Name: "payments.tags", Value: `[1,{"a":"b"}]`, Type: models.TypeJSON},
+				{Name: "payments.timeout", Value: "10", Type: models.TypeInt},
+			},
+		},
+		{
+			name:          "json not an object",
+			format:        JSON,
+			data:          `["a"]`,
+			expectedError: true,
+		},
+		{
+			name:          "unknown format",
+			format:        "ini",
+			data:          "a=b",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := Parse(tc.format, []byte(tc.data))
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, entries)
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]string{
+		".env":                   Env,
+		"config/.env.production": Env,
+		"production.env":         Env,
+		"settings.yml":           YAML,
+		"settings.yaml":          YAML,
+		"settings.json":          JSON,
+		"application.properties": Properties,
+		"settings.toml":          "",
+		"environment":            "",
+	}
+
+	for filename, expected := range tests {
+		assert.Equal(t, expected, FormatOf(filename), filename)
+	}
+}
+
+func TestRender(t *testing.T) {
+	entries := []Entry{
+		{Name: "payments.gateway.timeout", Value: "10", Type: models.TypeInt},
+		{Name: "payments.gateway", Value: "stripe", Type: models.TypeString},
+		{Name: "payments.ratio", Value: "2", Type: models.TypeFloat},
+		{Name: "payments.enabled", Value: "true", Type: models.TypeBool},
+		{Name: "payments.currencies", Value: `["eur",1]`, Type: models.TypeJSON},
+		{Name: "greeting", Value: "say \"hi\"\n$HOME `x` \\ café", Type: models.TypeString},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: Env,
+			expected: "greeting=\"say \\\"hi\\\"\\n\\$HOME \\`x\\` \\\\ café\"\n" +
+				"payments.currencies=\"[\\\"eur\\\",1]\"\n" +
+				"payments.enabled=\"true\"\n" +
+				"payments.gateway=\"stripe\"\n" +
+				"payments.gateway.timeout=\"10\"\n" +
+				"payments.ratio=\"2\"\n",
+		},
+		{
+			format: Properties,
+			expected: "greeting=say \"hi\"\\n$HOME `x` \\\\ caf\\u00e9\n" +
+				"payments.currencies=[\"eur\",1]\n" +
+				"payments.enabled=true\n" +
+				"payments.gateway=stripe\n" +
+				"payments.gateway.timeout=10\n" +
+				"payments.ratio=2\n",
+		},
+		{
+			format: YAML,
+			expected: "greeting: |-\n" +
+				"  say \"hi\"\n" +
+				"  $HOME `x` \\ café\n" +
+				"payments:\n" +
+				"  currencies:\n" +
+				"    - eur\n" +
+				"    - 1\n" +
+				"  enabled: true\n" +
+				"  gateway:\n" +
+				"    \"\": stripe\n" +
+				"    timeout: 10\n" +
+				"  ratio: 2\n",
+		},
+		{
+			format: JSON,
+			expected: "{\n" +
+				"  \"greeting\": \"say \\\"hi\\\"\\n$HOME `x` \\\\ café\",\n" +
+				"  \"payments\": {\n" +
+				"    \"currencies\": [\n" +
+				"      \"eur\",\n" +
+				"      1\n" +
+				"    ],\n" +
+				"    \"enabled\": true,\n" +
+				"    \"gateway\": {\n" +
+				"      \"\": \"stripe\",\n" +
+				"      \"timeout\": 10\n" +
+				"    },\n" +
+				"    \"ratio\": 2\n" +
+				"  }\n" +
+				"}\n",
+		},
+		{
+			format: TOML,
+			expected: "greeting = \"say \\\"hi\\\"\\n$HOME `x` \\\\ café\"\n" +
+				"\n" +
+				"[payments]\n" +
+				"currencies = [\"eur\", 1]\n" +
+				"enabled = true\n" +
+				"ratio = 2.0\n" +
+				"\n" +
+				"[payments.gateway]\n" +
+				"\"\" = \"stripe\"\n" +
+				"timeout = 10\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+		t.Run(tc.format, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := Render(tc.format, entries)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+		})
+	}
+}
+
+func TestNest(t *testing.T) {
+	entries := []Entry{
+		{Name: "db.port", Value: "5432", Type: models.TypeInt},
+		{Name: "db", Value: "primary"},
+		{Name: "db.replicas", Value: `["a",{"weight":2}]`, Type: models.TypeJSON},
+		{Name: "debug", Value: "yes", Type: models.TypeBool},
+	}
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{
+			"":         "primary",
+			"port":     int64(5432),
+			"replicas": []interface{}{"a", map[string]interface{}{"weight": int64(2)}},
+		},
+		// values that don't decode stay strings
+		"debug": "yes",
+	}
+
+	assert.Equal(t, expected, Nest(entries))
+
+	// the object of a json value doesn't take the children of its name
+	object := []Entry{
+		{Name: "a", Value: `{"x":1}`, Type: models.TypeJSON},
+		{Name: "a.y", Value: "2", Type: models.TypeInt},
+	}
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{
+			"":  map[string]interface{}{"x": int64(1)},
+			"y": int64(2),
+		},
+	}
+	assert.Equal(t, nested, Nest(object))
+	assert.Equal(t, nested, Nest([]Entry{object[1], object[0]}))
+
+	// a parent written after its children keeps its value the same way
+	reversed := []Entry{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		reversed = append(reversed, entries[i])
+	}
+	assert.Equal(t, expected, Nest(reversed))
+}
+
+func TestRenderErrors(t *testing.T) {
+	_, err := Render("ini", nil)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Render(Env, []Entry{{Name: "db host", Value: "localhost"}})
+	assert.Error(t, err)
+}
+
+func TestRenderTOMLNull(t *testing.T) {
+	entries := []Entry{
+		{Name: "list", Value: `[1,null]`, Type: models.TypeJSON},
+		{Name: "db.options", Value: `[{"ssl":null}]`, Type: models.TypeJSON},
+		{Name: "db.host", Value: "localhost"},
+		{Name: "port", Value: "8080", Type: models.TypeInt},
+	}
+
+	rendered, err := Render(TOML, entries)
+	require.NoError(t, err)
+	expected := `# list skipped: toml has no null
+port = 8080
+
+[db]
+host = "localhost"
+# options skipped: toml has no null
+`
+	assert.Equal(t, expected, string(rendered))
+}
+
+// TestRoundTrip reads back what Render wrote
+func TestRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{Name: "a", Value: "quote \" backslash \\ dollar $ newline \n tab \t"},
+		{Name: "a.b", Value: " leading space, = : # ! é 😀"},
+		{Name: "a.b.c", Value: ""},
+		{Name: "key with spaces", Value: "v"},
+	}
+
+	for _, format := range []string{Properties, YAML, JSON} {
+		data, err := Render(format, entries)
+		require.NoError(t, err, format)
+
+		parsed, err := Parse(format, data)
+		require.NoError(t, err, format)
+		assert.Equal(t, entries, parsed, format)
+	}
+
+	// json objects come back whole as strings, not as keys of their own
+	objects := []Entry{
+		{Name: "a", Value: `{"x":1}`, Type: models.TypeJSON},
+		{Name: "a.y", Value: "2", Type: models.TypeInt},
+		{Name: "b", Value: `{"z":{"w":true}}`, Type: models.TypeJSON},
+	}
+	for _, format := range []string{YAML, JSON} {
+		data, err := Render(format, objects)
+		require.NoError(t, err, format)
+
+		parsed, err := Parse(format, data)
+		require.NoError(t, err, format)
+		assert.Equal(t, []Entry{
+			{Name: "a", Value: `{"x":1}`},
+			{Name: "a.y", Value: "2", Type: models.TypeInt},
+			{Name: "b", Value: `{"z":{"w":true}}`},
+		}, parsed, format)
+	}
+
+	data, err := Render(Env, entries[:3])
+	require.NoError(t, err)
+	parsed, err := Parse(Env, data)
+	require.NoError(t, err)
+	assert.Equal(t, entries[:3], parsed)
+}
@@ -0,0 +1,170 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// parseProperties reads Java properties: the key ends at the first unescaped
+// '=', ':' or whitespace, lines ending with an odd number of backslashes go
+// on on the next line and # or ! start comments.
+func parseProperties(data string) ([]Entry, error) {
+	entries := []Entry{}
+
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for continues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		end := len(line)
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if strings.IndexByte("=: \t\f", line[j]) >= 0 {
+				end = j
+				break
+			}
+		}
+
+		rest := strings.TrimLeft(line[end:], " \t\f")
+		if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+
+		name, err := unescapeProperty(line[:end])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		value, err := unescapeProperty(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		entries = append(entries, Entry{Name: name, Value: value})
+	}
+
+	return entries, nil
+}
+
+// unicodeEscape decodes the 4 hex digits starting value
+func unicodeEscape(value string) (rune, bool) {
+	if len(value) < 4 {
+		return 0, false
+	}
+
+	code, err := strconv.ParseUint(value[:4], 16, 16)
+	if err != nil {
+		return 0, false
+	}
+
+	return rune(code), true
+}
+
+// continues reports whether line ends with an odd number of backslashes
+func continues(line string) bool {
+	backslashes := len(line) - len(strings.TrimRight(line, `\`))
+	return backslashes%2 == 1
+}
+
+func unescapeProperty(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, ok := unicodeEscape(value[i+1:])
+			if !ok {
+				return "", fmt.Errorf("malformed \\u escape")
+			}
+			i += 4
+			// characters outside the basic plane are escaped as surrogate pairs
+			rest := value[i+1:]
+			if utf16.IsSurrogate(r) && strings.HasPrefix(rest, `\u`) {
+				if low, ok := unicodeEscape(rest[2:]); ok {
+					r = utf16.DecodeRune(r, low)
+					i += 6
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// renderProperties writes key=value lines escaped like Java does, characters
+// outside printable ASCII as \\u escapes
+func renderProperties(entries []Entry) []byte {
+	var b bytes.Buffer
+	for _, entry := range entries {
+		b.WriteString(escapeProperty(entry.Name, true))
+		b.WriteByte('=')
+		b.WriteString(escapeProperty(entry.Value, false))
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes()
+}
+
+// escapeProperty escapes a key, or a value where only leading spaces matter
+func escapeProperty(value string, key bool) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			b.WriteString(`\ `)
+		case strings.ContainsRune("=:#!", r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, code := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04x`, code)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
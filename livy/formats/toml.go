@@ -0,0 +1,183 @@
+package formats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errNull is returned for values holding a null, TOML has none
+var errNull = errors.New("toml has no null")
+
+// bareKey matches the keys TOML doesn't need to quote
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func renderTOML(tree map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	err := writeTOMLTable(&b, nil, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeTOMLTable writes the values of table under its header, then its
+// subtables. Tables holding only subtables don't need a header.
+func writeTOMLTable(b *bytes.Buffer, path []string, table map[string]interface{}) error {
+	values, tables := []string{}, []string{}
+	for key, value := range table {
+		if _, ok := value.(map[string]interface{}); ok {
+			tables = append(tables, key)
+		} else {
+			values = append(values, key)
+		}
+	}
+	sort.Strings(values)
+	sort.Strings(tables)
+
+	if len(path) > 0 && (len(values) > 0 || len(tables) == 0) {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+
+		keys := []string{}
+		for _, key := range path {
+			keys = append(keys, tomlKey(key))
+		}
+		fmt.Fprintf(b, "[%s]\n", strings.Join(keys, "."))
+	}
+
+	for _, key := range values {
+		value, err := tomlValue(table[key])
+		if errors.Is(err, errNull) {
+			// leave the other keys exportable, tell why this one is missing
+			fmt.Fprintf(b, "# %s skipped: %s\n", tomlKey(key), err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("key %s: %w", strings.Join(append(path, key), KeySeparator), err)
+		}
+		fmt.Fprintf(b, "%s = %s\n", tomlKey(key), value)
+	}
+
+	for _, key := range tables {
+		err := writeTOMLTable(b, append(path[:len(path):len(path)], key), table[key].(map[string]interface{}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+
+	return tomlString(key)
+}
+
+func tomlValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return tomlString(value), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return tomlFloat(value), nil
+	case []interface{}:
+		items := []string{}
+		for _, item := range value {
+			encoded, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, encoded)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fields := []string{}
+		for _, key := range keys {
+			encoded, err := tomlValue(value[key])
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, tomlKey(key)+" = "+encoded)
+		}
+		if len(fields) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(fields, ", ") + " }", nil
+	case nil:
+		return "", errNull
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// tomlFloat writes f with the decimal point or exponent TOML floats need
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	encoded := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(encoded, ".e") {
+		encoded += ".0"
+	}
+
+	return encoded
+}
+
+// tomlString writes a basic string, control characters escaped
+func tomlString(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,264 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"livy/livy/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeySeparator joins the keys of nested objects, payments.gateway.timeout is
+// the timeout of the gateway of payments
+const KeySeparator = "."
+
+func parseYAML(data []byte) ([]Entry, error) {
+	document := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	return flatten("", document)
+}
+
+func parseJSON(data []byte) ([]Entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	document := map[string]interface{}{}
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+
+	return flatten("", document)
+}
+
+// flatten returns the values of object by dotted key, sorted by key. Numbers
+// and booleans keep their type, arrays are stored as json.
+func flatten(prefix string, object map[string]interface{}) ([]Entry, error) {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := []Entry{}
+	for _, key := range keys {
+		name := prefix + key
+		if key == "" && prefix != "" {
+			// the value of a name that is also a parent, as written by Render
+			name = strings.TrimSuffix(prefix, KeySeparator)
+		}
+		entry := Entry{Name: name}
+
+		switch value := object[key].(type) {
+		case map[string]interface{}:
+			children, err := flatten(name+KeySeparator, value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, children...)
+			continue
+		case map[interface{}]interface{}:
+			// yaml mappings with keys other than strings
+			converted := map[string]interface{}{}
+			for child, childValue := range value {
+				converted[fmt.Sprint(child)] = childValue
+			}
+			children, err := flatten(name+KeySeparator, converted)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, children...)
+			continue
+		case nil:
+			return nil, fmt.Errorf("key %s: null values can't be imported", name)
+		case string:
+			entry.Value = value
+		case bool:
+			entry.Value, entry.Type = strconv.FormatBool(value), models.TypeBool
+		case int, int64, uint64:
+			entry.Value, entry.Type = fmt.Sprint(value), models.TypeInt
+		case float64:
+			entry.Value, entry.Type = strconv.FormatFloat(value, 'g', -1, 64), models.TypeFloat
+		case json.Number:
+			entry.Value, entry.Type = value.String(), models.TypeFloat
+			if _, err := value.Int64(); err == nil {
+				entry.Type = models.TypeInt
+			}
+		case []interface{}:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", name, err)
+			}
+			entry.Value, entry.Type = string(encoded), models.TypeJSON
+		default:
+			entry.Value = fmt.Sprint(value)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// node is an object Nest made from dotted names. Objects of json values are
+// leaves, they never take children.
+type node map[string]interface{}
+
+// Nest turns the dotted names of entries into nested objects of the values
+// decoded by Value, in any order. A name that is both a value and the parent
+// of others keeps its value under the empty key.
+func Nest(entries []Entry) map[string]interface{} {
+	root := node{}
+	for _, entry := range entries {
+		value := Value(entry.Type, entry.Value)
+		segments := strings.Split(entry.Name, KeySeparator)
+
+		current := root
+		for _, segment := range segments[:len(segments)-1] {
+			child, ok := current[segment].(node)
+			if !ok {
+				child = node{}
+				// the value of a name moves down a level once it has children
+				if leaf, exists := current[segment]; exists {
+					child[""] = leaf
+				}
+				current[segment] = child
+			}
+			current = child
+		}
+
+		last := segments[len(segments)-1]
+		if child, ok := current[last].(node); ok {
+			child[""] = value
+		} else {
+			current[last] = value
+		}
+	}
+
+	return root.objects()
+}
+
+// objects returns n with its nodes turned into plain objects
+func (n node) objects() map[string]interface{} {
+	object := map[string]interface{}{}
+	for key, value := range n {
+		if child, ok := value.(node); ok {
+			object[key] = child.objects()
+		} else {
+			object[key] = value
+		}
+	}
+
+	return object
+}
+
+// documentEntries returns entries with the json values holding an object
+// kept as strings. Nested files would read their fields back as keys.
+func documentEntries(entries []Entry) []Entry {
+	document := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type == models.TypeJSON {
+			if _, ok := Value(entry.Type, entry.Value).(map[string]interface{}); ok {
+				entry.Type = models.TypeString
+			}
+		}
+		document = append(document, entry)
+	}
+
+	return document
+}
+
+// Value returns value decoded as valueType. Durations and urls stay strings,
+// values that don't decode are returned as they are.
+func Value(valueType, value string) interface{} {
+	switch valueType {
+	case models.TypeInt:
+		if decoded, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return decoded
+		}
+	case models.TypeFloat:
+		if decoded, err := strconv.ParseFloat(value, 64); err == nil {
+			return decoded
+		}
+	case models.TypeBool:
+		if decoded, err := strconv.ParseBool(value); err == nil {
+			return decoded
+		}
+	case models.TypeJSON:
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err == nil {
+			return numbers(decoded)
+		}
+	}
+
+	return value
+}
+
+// numbers replaces the json.Number of value with int64 or float64
+func numbers(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if integer, err := value.Int64(); err == nil {
+			return integer
+		}
+		float, _ := value.Float64()
+		return float
+	case []interface{}:
+		for i := range value {
+			value[i] = numbers(value[i])
+		}
+	case map[string]interface{}:
+		for key := range value {
+			value[key] = numbers(value[key])
+		}
+	}
+
+	return value
+}
+
+func renderYAML(tree map[string]interface{}) ([]byte, error) {
+	if len(tree) == 0 {
+		return []byte{}, nil
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func renderJSON(tree map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
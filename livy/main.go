@@ -2,15 +2,53 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"livy/livy/controllers"
 	"livy/livy/migrations"
 	"livy/livy/services"
+	"livy/livy/storages"
+	"livy/livy/storages/memory"
 	"livy/livy/storages/postgres"
+	"livy/livy/storages/sqlite"
 	"log"
+	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// newStorage picks the storage backend from LIVY_STORAGE, defaulting to postgres
+func newStorage() (storages.LivyRepo, error) {
+	switch os.Getenv("LIVY_STORAGE") {
+	case "", "postgres":
+		return postgres.New()
+	case "sqlite":
+		return sqlite.New()
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", os.Getenv("LIVY_STORAGE"))
+	}
+}
+
+// newMigration waits LIVY_MIGRATION_LOCK_TIMEOUT, like "30s", for other
+// instances migrating the database
+func newMigration(db storages.LivyRepo) (*migrations.LivyMigration, error) {
+	migration := migrations.New(db)
+
+	timeout := os.Getenv("LIVY_MIGRATION_LOCK_TIMEOUT")
+	if timeout == "" {
+		return migration, nil
+	}
+
+	lockTimeout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LIVY_MIGRATION_LOCK_TIMEOUT: %w", err)
+	}
+
+	return migration.WithLockTimeout(lockTimeout), nil
+}
+
 func main(){
 	// read config file
 	err := godotenv.Load("config/.env")
@@ -19,19 +57,32 @@ func main(){
 	}
 
 	// create database connection
-	db,err := postgres.New()
+	db,err := newStorage()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	ctx := context.Background()
 
+	migration, err := newMigration(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// livy migrate ... only manages the migrations
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(ctx, migration, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Run Migrations
-	migrations := migrations.New(db)
-	err = migrations.Run(ctx)
+	err = migration.Run(ctx)
 	if err != nil {
 		log.Fatal(err)
-	
+
 	}
 
 	log.Println("running SalesApp services")
@@ -42,5 +93,5 @@ func main(){
 	if err != nil {
 		log.Fatal(err)
 	}
-	
+
 }
\ No newline at end of file
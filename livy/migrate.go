@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"livy/livy/migrations"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: livy migrate [--dry-run] [status | up | down | goto VERSION]
+
+  status        list the migrations and when they were applied
+  up            apply every pending migration, the default
+  down          revert the last applied migration
+  goto VERSION  apply or revert migrations until the database is at VERSION
+  --dry-run     print the SQL of up, down or goto without running it
+`
+
+// runMigrate runs the migrate subcommand, args are the arguments after it
+func runMigrate(ctx context.Context, migration *migrations.LivyMigration, args []string, out io.Writer) error {
+	dryRun := false
+	rest := []string{}
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run", "-dry-run":
+			dryRun = true
+		case "--help", "-help", "-h":
+			fmt.Fprint(out, migrateUsage)
+			return nil
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	command := "up"
+	if len(rest) > 0 {
+		command, rest = rest[0], rest[1:]
+	}
+
+	target := 0
+	switch command {
+	case "status":
+		if len(rest) > 0 {
+			return fmt.Errorf("unexpected arguments %q\n%s", rest, migrateUsage)
+		}
+		return printStatus(ctx, migration, out)
+	case "up":
+		target = migration.Latest()
+	case "down":
+		current, err := migration.Current(ctx)
+		if err != nil {
+			return err
+		}
+		if current == 0 {
+			return errors.New("no migration to revert")
+		}
+		target = current - 1
+	case "goto":
+		if len(rest) == 0 {
+			return fmt.Errorf("goto needs a version\n%s", migrateUsage)
+		}
+		version, err := strconv.Atoi(rest[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		target, rest = version, rest[1:]
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, migrateUsage)
+	}
+
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q\n%s", rest, migrateUsage)
+	}
+
+	if dryRun {
+		return printPlan(ctx, migration, target, out)
+	}
+
+	return migration.Migrate(ctx, target)
+}
+
+func printStatus(ctx context.Context, migration *migrations.LivyMigration, out io.Writer) error {
+	statuses, err := migration.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	current := 0
+	for _, status := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		if status.Applied != nil {
+			state = "applied"
+			if status.Version > current {
+				current = status.Version
+			}
+			// rows of older releases have no time
+			if !status.Applied.AppliedAt.IsZero() {
+				appliedAt = status.Applied.AppliedAt.Format(time.RFC3339)
+			}
+		}
+		if status.Unknown() {
+			state = "unknown"
+		}
+		if status.Edited() {
+			state = "edited"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "database at version %d, latest is %d\n", current, migration.Latest())
+	return nil
+}
+
+func printPlan(ctx context.Context, migration *migrations.LivyMigration, target int, out io.Writer) error {
+	steps, err := migration.Plan(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Fprintln(out, "-- no migration needed")
+		return nil
+	}
+
+	for _, step := range steps {
+		direction := "up"
+		if step.Down {
+			direction = "down"
+		}
+
+		fmt.Fprintf(out, "-- %s %d %s\n", direction, step.Version, step.Name)
+		statements := strings.TrimSpace(step.Statements())
+		if statements != "" {
+			fmt.Fprintf(out, "%s\n", statements)
+		}
+		fmt.Fprintln(out)
+	}
+
+	return nil
+}
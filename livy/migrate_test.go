@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"livy/livy/migrations"
+	"livy/livy/storages/sqlite"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMigrate(t *testing.T) {
+	ctx := context.Background()
+	latest := migrations.New(&sqlite.SqliteWrapper{}).Latest()
+
+	tests := []struct {
+		name        string
+		setup       []string
+		args        []string
+		checkResult func(t *testing.T, out string, version int, err error)
+	}{
+		{
+			name: "status of a new database",
+			args: []string{"status"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "VERSION")
+				assert.Regexp(t, `2 +init_configuration_table +pending +-`, out)
+				assert.NotContains(t, out, "applied")
+				assert.Contains(t, out, "database at version 0")
+				assert.Equal(t, 0, version)
+			},
+		},
+		{
+			name:  "status after up",
+			args:  []string{"status"},
+			setup: []string{"up"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.NotContains(t, out, "pending")
+				assert.Contains(t, out, "applied")
+				assert.Equal(t, latest, version)
+			},
+		},
+		{
+			name: "up is the default",
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, latest, version)
+			},
+		},
+		{
+			name: "dry run prints the pending SQL",
+			args: []string{"--dry-run"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "-- up 2 init_configuration_table")
+				assert.Contains(t, out, "CREATE TABLE IF NOT EXISTS configuration")
+				assert.Equal(t, 0, version)
+			},
+		},
+		{
+			name:  "dry run of down",
+			setup: []string{"up"},
+			args:  []string{"down", "--dry-run"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Contains(t, out, "-- down 9 configuration_version")
+				assert.Contains(t, out, "DROP COLUMN version")
+				assert.NotContains(t, out, "-- down 8")
+				assert.Equal(t, latest, version)
+			},
+		},
+		{
+			name:  "down reverts one migration",
+			setup: []string{"up"},
+			args:  []string{"down"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, latest-1, version)
+			},
+		},
+		{
+			name: "down without migrations",
+			args: []string{"down"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				assert.EqualError(t, err, "no migration to revert")
+			},
+		},
+		{
+			name:  "goto",
+			setup: []string{"up"},
+			args:  []string{"goto", "4"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 4, version)
+			},
+		},
+		{
+			name: "goto unknown version",
+			args: []string{"goto", "1000"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				assert.ErrorIs(t, err, migrations.ErrUnknownVersion)
+			},
+		},
+		{
+			name: "goto without version",
+			args: []string{"goto"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				assert.ErrorContains(t, err, "goto needs a version")
+			},
+		},
+		{
+			name: "unknown command",
+			args: []string{"sideways"},
+			checkResult: func(t *testing.T, out string, version int, err error) {
+				assert.ErrorContains(t, err, `unknown migrate command "sideways"`)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := sqlite.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			if len(tc.setup) > 0 {
+				require.NoError(t, runMigrate(ctx, migrations.New(db), tc.setup, &bytes.Buffer{}))
+			}
+
+			out := &bytes.Buffer{}
+			err = runMigrate(ctx, migrations.New(db), tc.args, out)
+
+			version, versionErr := db.GetDBVersion(ctx)
+			require.NoError(t, versionErr)
+
+			tc.checkResult(t, out.String(), version, err)
+		})
+	}
+}
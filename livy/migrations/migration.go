@@ -2,59 +2,428 @@ package migrations
 
 import (
 	"context"
-	"livy/livy/migrations/script"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"livy/livy/models"
 	"livy/livy/storages"
 	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
+var (
+	// ErrChecksumMismatch is returned when an applied migration was edited afterwards
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrUnknownVersion is returned when the database is at a version this
+	// release doesn't have, or asked to go to one
+	ErrUnknownVersion = errors.New("unknown migration version")
+	// ErrLockTimeout is returned when another instance kept migrating for
+	// longer than the lock timeout
+	ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+)
+
+// DefaultLockTimeout is how long Migrate waits for another instance
+// migrating the same database
+const DefaultLockTimeout = time.Minute
+
+// Migration is one step of the database schema, read from the files
+// sql/<dialect>/<version>_<name>.up.sql and .down.sql. Down undoes Up, both
+// run in a transaction together with the db_version row recording them.
+type Migration struct {
+	Version int
+	Name    string
+	// Checksum is the sha256 of Up, it changes whenever the migration is edited
+	Checksum string
+	Up       string
+	Down     string
+}
+
+//go:embed sql
+var files embed.FS
+
+// defaultDialect lists the migrations of storages without a dialect, every
+// dialect has the same versions
+const defaultDialect = "postgres"
+
 type LivyMigration struct {
-	db storages.LivyRepo
+	db         storages.LivyRepo
+	migrations []Migration
+	// err is why the migrations of db couldn't be loaded, returned by Migrate
+	err         error
+	lockTimeout time.Duration
 }
 
 func New(db storages.LivyRepo) *LivyMigration {
+	migrations, err := load(db.Dialect())
+
+	return &LivyMigration{
+		db:          db,
+		migrations:  migrations,
+		err:         err,
+		lockTimeout: DefaultLockTimeout,
+	}
+}
+
+// WithLockTimeout returns a copy of m waiting at most timeout for another
+// instance to finish migrating
+func (m *LivyMigration) WithLockTimeout(timeout time.Duration) *LivyMigration {
 	return &LivyMigration{
-		db:db,
+		db:          m.db,
+		migrations:  m.migrations,
+		err:         m.err,
+		lockTimeout: timeout,
 	}
 }
 
-func (m *LivyMigration) getMigrateFunc(ctx context.Context) []func() {
-	migrations := []func(){}
-	// version 1
-	migrations = append(migrations, func(){m.db.InitiateTable(ctx)})
-	// version 2
-	migrations = append(migrations, func(){script.Up2(ctx, m.db)})
+// load reads the migrations of dialect sorted by version. Storages without a
+// dialect get the versions of defaultDialect without statements, they only
+// record them.
+func load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	if dialect == "" {
+		dir = path.Join("sql", defaultDialect)
+	}
+
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, up := strings.CutSuffix(file, ".up.sql")
+		if !up {
+			var down bool
+			base, down = strings.CutSuffix(file, ".down.sql")
+			if !down {
+				return nil, fmt.Errorf("migration %s: not an .up.sql or .down.sql file", file)
+			}
+		}
+
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version < 1 || name == "" {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", file)
+		}
+
+		statements, err := fs.ReadFile(files, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %s: version %d is already %s", file, version, migration.Name)
+		}
+
+		if up {
+			sum := sha256.Sum256(statements)
+			migration.Checksum = hex.EncodeToString(sum[:])
+			migration.Up = string(statements)
+		} else {
+			migration.Down = string(statements)
+		}
+	}
+
+	migrations := []Migration{}
+	for version := 1; version <= len(byVersion); version++ {
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d is missing", version)
+		}
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d %s has no .up.sql file", version, migration.Name)
+		}
+
+		if dialect == "" {
+			migration.Up = ""
+			migration.Down = ""
+		}
+		migrations = append(migrations, *migration)
+	}
 
-	return migrations
+	return migrations, nil
 }
 
+// Latest returns the version of the last migration
+func (m *LivyMigration) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Run applies every pending migration
 func (m *LivyMigration) Run(ctx context.Context) error {
-	version,err := m.db.GetDBVersion(ctx)
+	return m.Migrate(ctx, m.Latest())
+}
+
+// Migrate applies or reverts migrations until the database is at version
+// target, 0 reverts them all. It stops at the first failing migration, the
+// ones before it stay applied. Instances migrating the same database wait
+// for each other, the ones coming last find nothing left to do.
+func (m *LivyMigration) Migrate(ctx context.Context, target int) (err error) {
+	if m.err != nil {
+		return m.err
+	}
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlockErr := unlock()
+		if err == nil && unlockErr != nil {
+			err = fmt.Errorf("failed to release the migration lock: %w", unlockErr)
+		}
+	}()
+
+	// only the holder of the lock creates or upgrades db_version
+	err = m.db.InitiateTable(ctx)
 	if err != nil {
-		log.Println(err.Error())
-		if err.Error() == `pq: relation "db_version" does not exist` {
-			version = 0
+		return fmt.Errorf("failed to create db_version: %w", err)
+	}
+
+	steps, err := m.Plan(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		log.Println("no migration needed")
+		return nil
+	}
+
+	for _, step := range steps {
+		if step.Down {
+			log.Println("revert migration version:", step.Version)
+			err = m.down(ctx, step.Migration)
 		} else {
+			log.Println("run migration version:", step.Version)
+			err = m.up(ctx, step.Migration)
+		}
+		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// Step is a migration run by Migrate, reverted when Down is set
+type Step struct {
+	Migration
+	Down bool
+}
 
+// Statements returns the SQL the step runs
+func (s Step) Statements() string {
+	if s.Down {
+		return s.Migration.Down
+	}
+
+	return s.Migration.Up
+}
+
+// Plan returns the steps Migrate would run to reach target, in order, without
+// running them
+func (m *LivyMigration) Plan(ctx context.Context, target int) ([]Step, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if target < 0 || target > m.Latest() {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	version, err := m.Current(ctx)
+	if err != nil {
+		return nil, err
 	}
 	log.Println("current version:", version)
 
-	migrateFunc := m.getMigrateFunc(ctx)
-	if len(migrateFunc) == version {
-		log.Println("no migration needed")
-	} else if version < len(migrateFunc) {
-		for i := version; i < len(migrateFunc); i++ {
-			log.Println("run migration version:", i+1)
-			migrateFunc[i]()
-			if i > 0 {
-				// up version
-				err := m.db.InsertDBVersion(ctx, i+1)
-				if err != nil {
-					return err
-				}
+	steps := []Step{}
+	for _, migration := range m.migrations {
+		if migration.Version > version && migration.Version <= target {
+			steps = append(steps, Step{Migration: migration})
+		}
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= version && migration.Version > target {
+			steps = append(steps, Step{Migration: migration, Down: true})
+		}
+	}
+
+	return steps, nil
+}
+
+// Status is a migration and the db_version row recording it, Applied is nil
+// while the migration is pending
+type Status struct {
+	Migration
+	Applied *models.DBVersion
+}
+
+// Unknown reports whether the database has the migration but this release
+// doesn't, loaded migrations always have a checksum
+func (s Status) Unknown() bool {
+	return s.Checksum == ""
+}
+
+// Edited reports whether the migration changed after it was applied
+func (s Status) Edited() bool {
+	return s.Applied != nil && !s.Unknown() && s.Applied.Checksum != "" && s.Applied.Checksum != s.Checksum
+}
+
+// Status lists every migration with the row recording it. Versions of the
+// database this release doesn't know come last, with only their row.
+func (m *LivyMigration) Status(ctx context.Context) ([]Status, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	known := map[int]bool{}
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		for i := range applied {
+			if applied[i].Version == migration.Version {
+				status.Applied = &applied[i]
 			}
 		}
+		statuses = append(statuses, status)
+		known[migration.Version] = true
+	}
+
+	for i, row := range applied {
+		if !known[row.Version] {
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: row.Version, Name: row.Name},
+				Applied:   &applied[i],
+			})
+		}
+	}
+
+	return statuses, nil
+}
+
+// lock takes the migration lock of the database, waiting at most lockTimeout
+func (m *LivyMigration) lock(ctx context.Context) (func() error, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	unlock, err := m.db.LockMigrations(lockCtx)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("%w after %s", ErrLockTimeout, m.lockTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take the migration lock: %w", err)
 	}
+
+	return unlock, nil
+}
+
+// applied returns the rows of db_version, none while the table is missing. It
+// only reads, Status and Plan must leave the database they inspect as it is.
+func (m *LivyMigration) applied(ctx context.Context) ([]models.DBVersion, error) {
+	applied, err := m.db.GetAllDBVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read db_version: %w", err)
+	}
+
+	return applied, nil
+}
+
+// Current returns the version of the database after checking the applied
+// migrations are known and weren't edited
+func (m *LivyMigration) Current(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for _, status := range statuses {
+		if status.Applied == nil {
+			continue
+		}
+		if status.Unknown() {
+			return 0, fmt.Errorf("%w: database is at version %d", ErrUnknownVersion, status.Version)
+		}
+		// rows written before checksums were recorded are trusted
+		if status.Edited() {
+			return 0, fmt.Errorf("%w: version %d %s", ErrChecksumMismatch, status.Version, status.Name)
+		}
+		if status.Version > version {
+			version = status.Version
+		}
+	}
+
+	return version, nil
+}
+
+func (m *LivyMigration) up(ctx context.Context, migration Migration) error {
+	err := m.db.Atomic(ctx, func(tx storages.LivyRepo) error {
+		err := exec(ctx, tx, migration.Up)
+		if err != nil {
+			return err
+		}
+
+		return tx.InsertDBVersion(ctx, models.DBVersion{
+			Version:  migration.Version,
+			Name:     migration.Name,
+			Checksum: migration.Checksum,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+func (m *LivyMigration) down(ctx context.Context, migration Migration) error {
+	err := m.db.Atomic(ctx, func(tx storages.LivyRepo) error {
+		err := exec(ctx, tx, migration.Down)
+		if err != nil {
+			return err
+		}
+
+		return tx.DeleteDBVersion(ctx, migration.Version)
+	})
+	if err != nil {
+		return fmt.Errorf("revert migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+// exec runs statements unless the file only holds comments
+func exec(ctx context.Context, db storages.LivyRepo, statements string) error {
+	for _, line := range strings.Split(statements, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return db.ExecMigration(ctx, statements)
+		}
+	}
+
+	return nil
+}
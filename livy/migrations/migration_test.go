@@ -0,0 +1,155 @@
+package migrations
+
+import (
+	"context"
+	"livy/livy/storages/sqlite"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	postgres, err := load("postgres")
+	require.NoError(t, err)
+	require.NotEmpty(t, postgres)
+
+	sqlite, err := load("sqlite")
+	require.NoError(t, err)
+	require.Len(t, sqlite, len(postgres))
+
+	memory, err := load("")
+	require.NoError(t, err)
+	require.Len(t, memory, len(postgres))
+
+	for i, migration := range postgres {
+		assert.Equal(t, i+1, migration.Version)
+		assert.NotEmpty(t, migration.Name)
+		assert.Len(t, migration.Checksum, 64)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+
+		// every dialect has the same versions
+		assert.Equal(t, migration.Name, sqlite[i].Name)
+		assert.Equal(t, migration.Name, memory[i].Name)
+		assert.Empty(t, memory[i].Up)
+		assert.Empty(t, memory[i].Down)
+	}
+
+	_, err = load("oracle")
+	assert.Error(t, err)
+}
+
+func TestMigrateStopsOnError(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	m := &LivyMigration{
+		db:          db,
+		lockTimeout: DefaultLockTimeout,
+		migrations: []Migration{
+			{Version: 1, Name: "first", Checksum: "1", Up: "CREATE TABLE first (id TEXT);", Down: "DROP TABLE first;"},
+			{Version: 2, Name: "second", Checksum: "2", Up: "CREATE TABLE second (id TEXT); CREATE TABLE broken ("},
+			{Version: 3, Name: "third", Checksum: "3", Up: "CREATE TABLE third (id TEXT);"},
+		},
+	}
+
+	tables := func() []string {
+		rows, err := db.GetData(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT IN ('db_version', 'migration_lock') ORDER BY name")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		names := []string{}
+		for rows.Next() {
+			name := ""
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		return names
+	}
+
+	err = m.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2 second")
+
+	version, err := db.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// the failed migration was rolled back with its transaction
+	assert.Equal(t, []string{"first"}, tables())
+
+	err = m.Migrate(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, tables())
+
+	err = m.Migrate(ctx, 4)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestInspectLeavesDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	columns := func(t *testing.T, db *sqlite.SqliteWrapper) []string {
+		rows, err := db.GetData(ctx, "SELECT name FROM pragma_table_info('db_version') ORDER BY name")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		names := []string{}
+		for rows.Next() {
+			name := ""
+			require.NoError(t, rows.Scan(&name))
+			names = append(names, name)
+		}
+		return names
+	}
+
+	t.Run("new database", func(t *testing.T) {
+		db, err := sqlite.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		m := New(db)
+		version, err := m.Current(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, version)
+
+		steps, err := m.Plan(ctx, m.Latest())
+		require.NoError(t, err)
+		assert.Len(t, steps, m.Latest())
+
+		_, err = m.Status(ctx)
+		require.NoError(t, err)
+
+		assert.Empty(t, columns(t, db), "db_version must not be created")
+	})
+
+	t.Run("table of an older release", func(t *testing.T) {
+		db, err := sqlite.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.UpdateData(ctx, "CREATE TABLE db_version (id TEXT PRIMARY KEY, version INTEGER)")
+		require.NoError(t, err)
+		_, err = db.InsertData(ctx, "INSERT INTO db_version (id, version) VALUES ('1', 1)")
+		require.NoError(t, err)
+
+		m := New(db)
+		statuses, err := m.Status(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, statuses[0].Applied)
+		assert.Empty(t, statuses[0].Applied.Checksum)
+
+		version, err := m.Current(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, version)
+
+		assert.Equal(t, []string{"id", "version"}, columns(t, db), "db_version must not be altered")
+
+		// migrating upgrades the table
+		require.NoError(t, m.Run(ctx))
+		assert.Equal(t, []string{"applied_at", "checksum", "id", "name", "version"}, columns(t, db))
+	})
+}
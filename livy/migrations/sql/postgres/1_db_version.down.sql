@@ -0,0 +1 @@
+-- db_version stays, it records the versions of the other migrations
@@ -0,0 +1,2 @@
+-- db_version is created by the migrator before it reads the applied
+-- versions, this migration only records it
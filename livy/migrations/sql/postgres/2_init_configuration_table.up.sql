@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS configuration (
+	id UUID PRIMARY KEY,
+	configname TEXT,
+	value TEXT
+);
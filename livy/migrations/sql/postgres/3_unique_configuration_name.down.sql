@@ -0,0 +1,2 @@
+ALTER TABLE configuration
+DROP CONSTRAINT configuration_configname_key;
@@ -0,0 +1,7 @@
+-- keep the oldest row of every duplicated name
+DELETE FROM configuration a
+USING configuration b
+WHERE a.configname = b.configname AND a.ctid > b.ctid;
+
+ALTER TABLE configuration
+ADD CONSTRAINT configuration_configname_key UNIQUE (configname);
@@ -0,0 +1,11 @@
+-- fails when a name is used in several namespaces, clean them up first
+ALTER TABLE configuration
+DROP CONSTRAINT configuration_namespace_configname_key;
+
+ALTER TABLE configuration
+DROP COLUMN namespace;
+
+ALTER TABLE configuration
+ADD CONSTRAINT configuration_configname_key UNIQUE (configname);
+
+DROP TABLE namespace;
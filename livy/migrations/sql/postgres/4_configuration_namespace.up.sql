@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS namespace (
+	id UUID PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+INSERT INTO namespace (id, name)
+VALUES (gen_random_uuid(), 'default')
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE configuration
+ADD COLUMN namespace TEXT NOT NULL DEFAULT 'default' REFERENCES namespace (name);
+
+ALTER TABLE configuration
+DROP CONSTRAINT configuration_configname_key;
+
+ALTER TABLE configuration
+ADD CONSTRAINT configuration_namespace_configname_key UNIQUE (namespace, configname);
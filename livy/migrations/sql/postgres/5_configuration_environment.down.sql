@@ -0,0 +1,9 @@
+-- fails when a name is used in several environments, clean them up first
+ALTER TABLE configuration
+DROP CONSTRAINT configuration_namespace_environment_configname_key;
+
+ALTER TABLE configuration
+DROP COLUMN environment;
+
+ALTER TABLE configuration
+ADD CONSTRAINT configuration_namespace_configname_key UNIQUE (namespace, configname);
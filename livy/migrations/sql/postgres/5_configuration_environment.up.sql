@@ -0,0 +1,8 @@
+ALTER TABLE configuration
+ADD COLUMN environment TEXT NOT NULL DEFAULT 'base';
+
+ALTER TABLE configuration
+DROP CONSTRAINT configuration_namespace_configname_key;
+
+ALTER TABLE configuration
+ADD CONSTRAINT configuration_namespace_environment_configname_key UNIQUE (namespace, environment, configname);
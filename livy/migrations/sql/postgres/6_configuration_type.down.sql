@@ -0,0 +1,2 @@
+ALTER TABLE configuration
+DROP COLUMN type;
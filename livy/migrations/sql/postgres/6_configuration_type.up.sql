@@ -0,0 +1,3 @@
+-- existing configurations were all strings
+ALTER TABLE configuration
+ADD COLUMN type TEXT NOT NULL DEFAULT 'string';
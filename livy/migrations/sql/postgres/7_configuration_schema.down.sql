@@ -0,0 +1 @@
+DROP TABLE configuration_schema;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS configuration_schema (
+	id UUID PRIMARY KEY,
+	namespace TEXT NOT NULL REFERENCES namespace (name) ON DELETE CASCADE,
+	configname TEXT NOT NULL,
+	schema TEXT NOT NULL,
+	CONSTRAINT configuration_schema_namespace_configname_key UNIQUE (namespace, configname)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS configuration_history (
+	id UUID PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	environment TEXT NOT NULL,
+	configname TEXT NOT NULL,
+	revision INT NOT NULL,
+	action TEXT NOT NULL,
+	old_value TEXT,
+	new_value TEXT,
+	type TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL,
+	configuration_id UUID,
+	CONSTRAINT configuration_history_revision_key UNIQUE (namespace, environment, configname, revision)
+);
+
+-- existing configurations start their history here, the table is new so
+-- their id can't clash. Revisions reuse the id of their configuration.
+INSERT INTO configuration_history
+(id, namespace, environment, configname, revision, action, new_value, type, actor, changed_at, configuration_id)
+SELECT id, namespace, environment, configname, 1, 'insert', value, type, 'migration', now(), id
+FROM configuration
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE configuration
+DROP COLUMN version;
+
+ALTER TABLE configuration_history
+DROP COLUMN version;
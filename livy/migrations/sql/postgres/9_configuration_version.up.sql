@@ -0,0 +1,6 @@
+-- every existing configuration starts at version 1, older revisions have none
+ALTER TABLE configuration
+ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE configuration_history
+ADD COLUMN version INT;
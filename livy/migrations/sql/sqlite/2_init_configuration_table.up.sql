@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS configuration (
+	id TEXT PRIMARY KEY,
+	configname TEXT,
+	value TEXT
+);
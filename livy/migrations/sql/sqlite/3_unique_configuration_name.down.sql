@@ -0,0 +1 @@
+DROP INDEX configuration_configname_key;
@@ -0,0 +1,5 @@
+-- keep the oldest row of every duplicated name
+DELETE FROM configuration
+WHERE rowid NOT IN (SELECT MIN(rowid) FROM configuration GROUP BY configname);
+
+CREATE UNIQUE INDEX IF NOT EXISTS configuration_configname_key ON configuration (configname);
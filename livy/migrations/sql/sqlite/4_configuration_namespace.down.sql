@@ -0,0 +1,8 @@
+-- fails when a name is used in several namespaces, clean them up first
+DROP INDEX configuration_namespace_configname_key;
+
+ALTER TABLE configuration DROP COLUMN namespace;
+
+CREATE UNIQUE INDEX configuration_configname_key ON configuration (configname);
+
+DROP TABLE namespace;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS namespace (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+-- a random version 4 uuid, like the ones livy generates
+INSERT OR IGNORE INTO namespace (id, name)
+VALUES (lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))), 'default');
+
+-- sqlite can't add a foreign key to an existing table, livy checks the
+-- namespace exists before inserting
+ALTER TABLE configuration ADD COLUMN namespace TEXT NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS configuration_configname_key;
+
+CREATE UNIQUE INDEX configuration_namespace_configname_key ON configuration (namespace, configname);
@@ -0,0 +1,6 @@
+-- fails when a name is used in several environments, clean them up first
+DROP INDEX configuration_namespace_environment_configname_key;
+
+ALTER TABLE configuration DROP COLUMN environment;
+
+CREATE UNIQUE INDEX configuration_namespace_configname_key ON configuration (namespace, configname);
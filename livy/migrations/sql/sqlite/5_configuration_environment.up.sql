@@ -0,0 +1,5 @@
+ALTER TABLE configuration ADD COLUMN environment TEXT NOT NULL DEFAULT 'base';
+
+DROP INDEX IF EXISTS configuration_namespace_configname_key;
+
+CREATE UNIQUE INDEX configuration_namespace_environment_configname_key ON configuration (namespace, environment, configname);
@@ -0,0 +1 @@
+ALTER TABLE configuration DROP COLUMN type;
@@ -0,0 +1,2 @@
+-- existing configurations were all strings
+ALTER TABLE configuration ADD COLUMN type TEXT NOT NULL DEFAULT 'string';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS namespace_delete_schema;
+
+DROP TABLE configuration_schema;
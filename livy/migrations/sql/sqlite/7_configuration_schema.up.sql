@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS configuration_schema (
+	id TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	configname TEXT NOT NULL,
+	schema TEXT NOT NULL,
+	UNIQUE (namespace, configname)
+);
+
+-- foreign keys are off by default in sqlite, cascade namespace deletes by hand
+CREATE TRIGGER IF NOT EXISTS namespace_delete_schema AFTER DELETE ON namespace
+BEGIN
+	DELETE FROM configuration_schema WHERE namespace = OLD.name;
+END;
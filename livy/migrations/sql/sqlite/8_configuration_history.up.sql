@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS configuration_history (
+	id TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL,
+	environment TEXT NOT NULL,
+	configname TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	old_value TEXT,
+	new_value TEXT,
+	type TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	changed_at TEXT NOT NULL,
+	configuration_id TEXT,
+	UNIQUE (namespace, environment, configname, revision)
+);
+
+-- existing configurations start their history here, the table is new so
+-- their id can't clash. changed_at has the fixed width layout livy writes,
+-- microseconds included. Revisions reuse the id of their configuration.
+INSERT OR IGNORE INTO configuration_history
+(id, namespace, environment, configname, revision, action, new_value, type, actor, changed_at, configuration_id)
+SELECT id, namespace, environment, configname, 1, 'insert', value, type, 'migration', strftime('%Y-%m-%dT%H:%M:%f000Z', 'now'), id
+FROM configuration;
@@ -0,0 +1,3 @@
+ALTER TABLE configuration DROP COLUMN version;
+
+ALTER TABLE configuration_history DROP COLUMN version;
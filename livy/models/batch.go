@@ -0,0 +1,21 @@
+package models
+
+// Operations of a configuration batch
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// BatchOperation is one change of a batch. Updates and deletes find the
+// configuration by name. An update must give the Version it changes, or set
+// AnyVersion to overwrite whatever is stored.
+type BatchOperation struct {
+	Op          string `json:"op"`
+	Name        string `json:"name"`
+	Environment string `json:"environment"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Version     int    `json:"version"`
+	AnyVersion  bool   `json:"any_version"`
+}
@@ -1,11 +1,42 @@
 package models
 
+// BaseEnvironment holds the value used by every environment without an override
+const BaseEnvironment = "base"
+
+// Types a configuration value can be declared as
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+	TypeJSON     = "json"
+	TypeURL      = "url"
+)
+
+// Configuration.Version starts at 1 and grows with every change, updates can
+// compare it to detect concurrent writes
 type Configuration struct {
 	Id string `json:"id"`
+	Namespace string `json:"namespace"`
+	Environment string `json:"environment"`
 	ConfigName string `json:"configname"`
 	Value string `json:"value"`
+	Type string `json:"type"`
+	Version int `json:"version"`
 }
 
 func (c *Configuration) Tablename() string{
 	return "configuration"
-}
\ No newline at end of file
+}
+
+// ResolvedConfiguration is the effective value of a configuration in an
+// environment, Source is the environment the value was taken from.
+type ResolvedConfiguration struct {
+	Namespace   string `json:"namespace"`
+	Environment string `json:"environment"`
+	ConfigName  string `json:"configname"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Source      string `json:"source"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Orders of ListConfiguration, a leading "-" sorts in descending order. Names
+// break ties and compare byte by byte.
+const (
+	SortName        = "name"
+	SortNameDesc    = "-name"
+	SortVersion     = "version"
+	SortVersionDesc = "-version"
+)
+
+// ConfigurationQuery selects the configurations of an environment returned by
+// ListConfiguration. Prefix and Contains match names case sensitively, After
+// is the last configuration of the previous page, only its ConfigName and
+// Version are read.
+type ConfigurationQuery struct {
+	Namespace   string
+	Environment string
+	Prefix      string
+	Contains    string
+	Sort        string
+	After       *Configuration
+	Limit       int
+}
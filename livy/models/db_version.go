@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DBVersion records a migration applied to the database. Checksum is the one
+// of the migration when it ran, rows written before checksums were recorded
+// have none.
+type DBVersion struct {
+	Id        string    `json:"id"`
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Checksum  string    `json:"checksum"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (v *DBVersion) Tablename() string {
+	return "db_version"
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Actions recorded in the configuration history
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ConfigurationRevision is one change of a configuration. Revisions are
+// numbered from 1 for every namespace, environment and name; OldValue is nil
+// for inserts and NewValue is nil for deletes. Version is the configuration
+// version after the change, 0 for changes made before versions existed.
+type ConfigurationRevision struct {
+	// ConfigurationId is empty for revisions recorded before it was kept
+	ConfigurationId string    `json:"configurationid"`
+	Namespace       string    `json:"namespace"`
+	Environment     string    `json:"environment"`
+	ConfigName      string    `json:"configname"`
+	Revision        int       `json:"revision"`
+	Action          string    `json:"action"`
+	OldValue        *string   `json:"oldvalue"`
+	NewValue        *string   `json:"newvalue"`
+	Type            string    `json:"type"`
+	Version         int       `json:"version"`
+	Actor           string    `json:"actor"`
+	ChangedAt       time.Time `json:"changedat"`
+}
+
+func (r *ConfigurationRevision) Tablename() string {
+	return "configuration_history"
+}
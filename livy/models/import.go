@@ -0,0 +1,21 @@
+package models
+
+// Import modes, they decide what happens to keys that already exist
+const (
+	ImportSkip      = "skip"
+	ImportOverwrite = "overwrite"
+	ImportFail      = "fail"
+)
+
+// Results of an imported key
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+)
+
+// ImportResult reports what an import did with one key
+type ImportResult struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// DefaultNamespace holds every configuration created without a namespace
+const DefaultNamespace = "default"
+
+type Namespace struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (n *Namespace) Tablename() string {
+	return "namespace"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "encoding/json"
+
+// Schema is the JSON Schema every value of ConfigName must match, in every
+// environment of the namespace
+type Schema struct {
+	Id         string          `json:"id"`
+	Namespace  string          `json:"namespace"`
+	ConfigName string          `json:"configname"`
+	Schema     json.RawMessage `json:"schema"`
+}
+
+func (s *Schema) Tablename() string {
+	return "configuration_schema"
+}
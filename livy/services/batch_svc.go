@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"livy/livy/models"
+	"livy/livy/storages"
+)
+
+// MaxBatchOperations caps the number of operations of a single batch
+const MaxBatchOperations = 100
+
+// ApplyBatch applies operations in order in namespace, either all of them are
+// kept or none. The error of the first failing operation is returned, wrapped
+// with its position.
+func (s *LivySvc) ApplyBatch(namespace string, operations []models.BatchOperation) error {
+	if len(operations) == 0 {
+		return fmt.Errorf("%w: a batch needs at least one operation", ErrValidation)
+	}
+	if len(operations) > MaxBatchOperations {
+		return fmt.Errorf("%w: a batch can't have more than %d operations", ErrValidation, MaxBatchOperations)
+	}
+
+	return s.db.Atomic(s.ctx, func(repo storages.LivyRepo) error {
+		tx := &LivySvc{
+			db:  repo,
+			ctx: s.ctx,
+		}
+
+		for i, operation := range operations {
+			err := tx.applyOperation(namespace, operation)
+			if err != nil {
+				return fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *LivySvc) applyOperation(namespace string, operation models.BatchOperation) error {
+	configuration := models.Configuration{
+		Namespace:   namespace,
+		Environment: operation.Environment,
+		ConfigName:  operation.Name,
+		Value:       operation.Value,
+		Type:        operation.Type,
+		Version:     operation.Version,
+	}
+
+	switch operation.Op {
+	case models.OperationCreate:
+		return s.InsertConfiguration(configuration)
+	case models.OperationUpdate:
+		// like If-Match on single updates, concurrent writes can't be clobbered blindly
+		if operation.Version < 1 && !operation.AnyVersion {
+			return fmt.Errorf("%w: update needs the version it changes, or any_version", ErrValidation)
+		}
+
+		current, err := s.db.GetConfiguration(s.ctx, namespace, operation.Environment, operation.Name)
+		if err != nil {
+			return err
+		}
+		configuration.Id = current.Id
+		return s.UpdateConfiguration(configuration)
+	case models.OperationDelete:
+		return s.DeleteConfigurationByName(namespace, operation.Environment, operation.Name)
+	default:
+		return fmt.Errorf("%w: unknown operation %q, expected create, update or delete", ErrValidation, operation.Op)
+	}
+}
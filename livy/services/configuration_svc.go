@@ -1,9 +1,19 @@
 package services
 
-import "livy/livy/models"
+import (
+	"errors"
+	"fmt"
+	"livy/livy/models"
+	"strings"
+)
 
-func (s *LivySvc) GetAllConfiguration()([]models.Configuration,error){
-	res, err := s.db.GetAllConfiguration(s.ctx)
+func (s *LivySvc) GetAllConfiguration(namespace, environment string)([]models.Configuration,error){
+	_, err := s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return []models.Configuration{} , err
+	}
+
+	res, err := s.db.GetAllConfiguration(s.ctx, namespace, environment)
 	if err != nil {
 		return []models.Configuration{} , err
 	}
@@ -11,8 +21,8 @@ func (s *LivySvc) GetAllConfiguration()([]models.Configuration,error){
 	return res, nil
 }
 
-func (s *LivySvc) GetConfiguration(configname string)(models.Configuration, error){
-	res, err := s.db.GetConfiguration(s.ctx,configname)
+func (s *LivySvc) GetConfiguration(namespace, environment, configname string)(models.Configuration, error){
+	res, err := s.db.GetConfiguration(s.ctx, namespace, environment, configname)
 	if err != nil {
 		return models.Configuration{} , err
 	}
@@ -20,8 +30,29 @@ func (s *LivySvc) GetConfiguration(configname string)(models.Configuration, erro
 	return res, nil
 }
 
-func (s *LivySvc) InsertConfiguration(configname,value string) error{
-	err := s.db.InsertConfiguration(s.ctx, configname,value)
+// InsertConfiguration creates a configuration, without a type it inherits the
+// type of the base environment value or defaults to string
+func (s *LivySvc) InsertConfiguration(configuration models.Configuration) error{
+	err := validateEnvironment(configuration.Environment)
+	if err != nil {
+		return err
+	}
+
+	if configuration.Type == "" {
+		configuration.Type = s.baseType(configuration)
+	}
+
+	err = validateConfiguration(configuration)
+	if err != nil {
+		return err
+	}
+
+	err = s.validateSchema(configuration)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.InsertConfiguration(s.ctx, configuration)
 	if err != nil {
 		return err
 	}
@@ -29,11 +60,134 @@ func (s *LivySvc) InsertConfiguration(configname,value string) error{
 	return nil
 }
 
-func (s *LivySvc) UpdateConfiguration(id,configname,value string) error{
-	err := s.db.UpdateConfiguration(s.ctx, configname, value,id)
+// UpdateConfiguration changes the configuration with the same id, without a
+// type the current type is kept
+func (s *LivySvc) UpdateConfiguration(configuration models.Configuration) error{
+	if configuration.Type == "" {
+		current, err := s.db.GetConfigurationById(s.ctx, configuration.Namespace, configuration.Id)
+		if err != nil {
+			return err
+		}
+		configuration.Type = current.Type
+	}
+
+	err := validateConfiguration(configuration)
+	if err != nil {
+		return err
+	}
+
+	err = s.validateSchema(configuration)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.UpdateConfiguration(s.ctx, configuration)
 	if err != nil {
 		return err
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// UpsertConfiguration sets the value of a configuration and reports whether it
+// was created, without a type the current or inherited type is used
+func (s *LivySvc) UpsertConfiguration(configuration models.Configuration) (bool, error) {
+	err := validateEnvironment(configuration.Environment)
+	if err != nil {
+		return false, err
+	}
+
+	if configuration.Type == "" {
+		current, err := s.db.GetConfiguration(s.ctx, configuration.Namespace, configuration.Environment, configuration.ConfigName)
+		switch {
+		case err == nil:
+			configuration.Type = current.Type
+		case errors.Is(err, ErrNotFound):
+			configuration.Type = s.baseType(configuration)
+		default:
+			return false, err
+		}
+	}
+
+	err = validateConfiguration(configuration)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.validateSchema(configuration)
+	if err != nil {
+		return false, err
+	}
+
+	return s.db.UpsertConfiguration(s.ctx, configuration)
+}
+
+func (s *LivySvc) DeleteConfiguration(namespace, id string) error {
+	return s.db.DeleteConfiguration(s.ctx, namespace, id)
+}
+
+func (s *LivySvc) DeleteConfigurationByName(namespace, environment, configname string) error {
+	return s.db.DeleteConfigurationByName(s.ctx, namespace, environment, configname)
+}
+
+// ResolveConfiguration returns the value of configname in environment, falling
+// back to the base environment when there is no override
+func (s *LivySvc) ResolveConfiguration(namespace, environment, configname string) (models.ResolvedConfiguration, error) {
+	resolved := models.ResolvedConfiguration{
+		Namespace:   namespace,
+		Environment: environment,
+		ConfigName:  configname,
+	}
+
+	configuration, err := s.db.GetConfiguration(s.ctx, namespace, environment, configname)
+	if errors.Is(err, ErrNotFound) && environment != models.BaseEnvironment {
+		configuration, err = s.db.GetConfiguration(s.ctx, namespace, models.BaseEnvironment, configname)
+	}
+	if err != nil {
+		return models.ResolvedConfiguration{}, err
+	}
+
+	resolved.Value = configuration.Value
+	resolved.Type = configuration.Type
+	resolved.Source = configuration.Environment
+
+	return resolved, nil
+}
+
+// baseType returns the type declared by the base environment value of
+// configuration, string when there is none
+func (s *LivySvc) baseType(configuration models.Configuration) string {
+	if configuration.Environment != models.BaseEnvironment {
+		base, err := s.db.GetConfiguration(s.ctx, configuration.Namespace, models.BaseEnvironment, configuration.ConfigName)
+		if err == nil {
+			return base.Type
+		}
+	}
+
+	return models.TypeString
+}
+
+func validateEnvironment(environment string) error {
+	if !namePattern.MatchString(environment) {
+		return fmt.Errorf("%w: environment must be lowercase letters, digits, '-' or '_'", ErrValidation)
+	}
+
+	return nil
+}
+
+func validateConfigName(configname string) error {
+	if strings.TrimSpace(configname) == "" {
+		return fmt.Errorf("%w: configuration name can't be empty", ErrValidation)
+	}
+
+	return nil
+}
+
+func validateConfiguration(configuration models.Configuration) error {
+	err := validateConfigName(configuration.ConfigName)
+	if err != nil {
+		return err
+	}
+
+	return validateValue(configuration.Type, configuration.Value)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"livy/livy/storages"
+)
+
+// Domain errors returned by LivySvc, storage errors are passed through so the
+// same sentinels work for both layers.
+var (
+	ErrNotFound      = storages.ErrNotFound
+	ErrAlreadyExists = storages.ErrAlreadyExists
+	ErrConflict      = storages.ErrConflict
+	ErrStaleVersion  = storages.ErrStaleVersion
+	ErrValidation    = errors.New("validation failed")
+)
+
+// FieldError is a single reason a value was rejected, Field is the JSON pointer
+// of the offending part of the value, empty for the whole value
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every field of a value that doesn't match its
+// schema. It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	ConfigName string
+	Fields     []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: value of %s doesn't match its schema", ErrValidation, e.ConfigName)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"livy/livy/formats"
+	"livy/livy/models"
+)
+
+// ExportConfiguration renders the configurations of environment whose name
+// starts with prefix as a file in format
+func (s *LivySvc) ExportConfiguration(namespace, environment, prefix, format string) ([]byte, error) {
+	err := validateEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	configurations, err := s.listAll(models.ConfigurationQuery{
+		Namespace:   namespace,
+		Environment: environment,
+		Prefix:      prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []formats.Entry{}
+	for _, configuration := range configurations {
+		entries = append(entries, formats.Entry{
+			Name:  configuration.ConfigName,
+			Value: configuration.Value,
+			Type:  configuration.Type,
+		})
+	}
+
+	data, err := formats.Render(format, entries)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fmt"
+	"livy/livy/models"
+	"time"
+)
+
+// MaxHistoryLimit caps the number of revisions returned by a single call
+const MaxHistoryLimit = 100
+
+// GetConfigurationHistory returns a page of the revisions of configname, newest
+// first. Names that never existed are reported with ErrNotFound.
+func (s *LivySvc) GetConfigurationHistory(namespace, environment, configname string, limit, offset int) ([]models.ConfigurationRevision, error) {
+	if limit < 1 || limit > MaxHistoryLimit {
+		return []models.ConfigurationRevision{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxHistoryLimit)
+	}
+	if offset < 0 {
+		return []models.ConfigurationRevision{}, fmt.Errorf("%w: offset can't be negative", ErrValidation)
+	}
+
+	revisions, err := s.db.GetConfigurationHistory(s.ctx, namespace, environment, configname, limit, offset)
+	if err != nil {
+		return []models.ConfigurationRevision{}, err
+	}
+
+	if len(revisions) == 0 && offset == 0 {
+		return []models.ConfigurationRevision{}, fmt.Errorf("configuration %s has no history: %w", configname, ErrNotFound)
+	}
+
+	return revisions, nil
+}
+
+// RollbackConfiguration restores the value and type configname had at
+// revision, as a new change. The value is validated again, a schema added
+// since then may refuse it.
+func (s *LivySvc) RollbackConfiguration(namespace, environment, configname string, revision int) (models.Configuration, error) {
+	// revisions start at 1, a zero value is a missing revision, not a request
+	if revision < 1 {
+		return models.Configuration{}, fmt.Errorf("%w: revision must be positive", ErrValidation)
+	}
+
+	target, err := s.db.GetConfigurationRevision(s.ctx, namespace, environment, configname, revision)
+	if err != nil {
+		return models.Configuration{}, err
+	}
+
+	if target.NewValue == nil {
+		return models.Configuration{}, fmt.Errorf("%w: revision %d deleted %s, there is no value to restore", ErrValidation, revision, configname)
+	}
+
+	_, err = s.UpsertConfiguration(models.Configuration{
+		Namespace:   namespace,
+		Environment: environment,
+		ConfigName:  configname,
+		Value:       *target.NewValue,
+		Type:        target.Type,
+	})
+	if err != nil {
+		return models.Configuration{}, err
+	}
+
+	return s.db.GetConfiguration(s.ctx, namespace, environment, configname)
+}
+
+// RollbackToPreviousRevision undoes the latest change of configname by
+// restoring the revision before it, see RollbackConfiguration
+func (s *LivySvc) RollbackToPreviousRevision(namespace, environment, configname string) (models.Configuration, error) {
+	latest, err := s.GetConfigurationHistory(namespace, environment, configname, 1, 0)
+	if err != nil {
+		return models.Configuration{}, err
+	}
+
+	previous := latest[0].Revision - 1
+	if previous < 1 {
+		return models.Configuration{}, fmt.Errorf("%w: configuration %s has no previous revision", ErrValidation, configname)
+	}
+
+	return s.RollbackConfiguration(namespace, environment, configname, previous)
+}
+
+// GetAllConfigurationAsOf returns the configurations of environment as they
+// were at asOf. Changes made before the history existed are not known, the
+// state starts when it was created.
+func (s *LivySvc) GetAllConfigurationAsOf(namespace, environment string, asOf time.Time) ([]models.Configuration, error) {
+	_, err := s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return []models.Configuration{}, err
+	}
+
+	return s.db.GetAllConfigurationAsOf(s.ctx, namespace, environment, asOf)
+}
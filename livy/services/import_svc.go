@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"livy/livy/formats"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"strings"
+)
+
+// MaxImportKeys caps the number of keys of a single import
+const MaxImportKeys = 10000
+
+// ImportConfiguration reads the keys of data in format and writes them to
+// environment, all of them or none. Existing keys are skipped, overwritten
+// or fail the import with ErrAlreadyExists depending on mode, skip when
+// empty. Keys without a type in the file keep their current or inherited
+// one.
+func (s *LivySvc) ImportConfiguration(namespace, environment, format string, data []byte, mode string) ([]models.ImportResult, error) {
+	switch mode {
+	case "":
+		mode = models.ImportSkip
+	case models.ImportSkip, models.ImportOverwrite, models.ImportFail:
+	default:
+		return nil, fmt.Errorf("%w: mode must be %s, %s or %s", ErrValidation, models.ImportSkip, models.ImportOverwrite, models.ImportFail)
+	}
+
+	entries, err := formats.Parse(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s file: %v", ErrValidation, format, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: the file has no keys", ErrValidation)
+	}
+	if len(entries) > MaxImportKeys {
+		return nil, fmt.Errorf("%w: an import can't have more than %d keys", ErrValidation, MaxImportKeys)
+	}
+
+	_, err = s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.ImportResult
+	err = s.db.Atomic(s.ctx, func(repo storages.LivyRepo) error {
+		results = []models.ImportResult{}
+		tx := &LivySvc{
+			db:  repo,
+			ctx: s.ctx,
+		}
+
+		existing := []string{}
+		for _, entry := range entries {
+			configuration := models.Configuration{
+				Namespace:   namespace,
+				Environment: environment,
+				ConfigName:  entry.Name,
+				Value:       entry.Value,
+				Type:        entry.Type,
+			}
+
+			result, err := tx.importEntry(configuration, mode)
+			if errors.Is(err, ErrAlreadyExists) && mode == models.ImportFail {
+				existing = append(existing, entry.Name)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("key %s: %w", entry.Name, err)
+			}
+
+			results = append(results, models.ImportResult{Name: entry.Name, Result: result})
+		}
+
+		if len(existing) > 0 {
+			return fmt.Errorf("%w: %s", ErrAlreadyExists, strings.Join(existing, ", "))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// importEntry writes configuration according to mode and returns the result
+func (s *LivySvc) importEntry(configuration models.Configuration, mode string) (string, error) {
+	current, err := s.db.GetConfiguration(s.ctx, configuration.Namespace, configuration.Environment, configuration.ConfigName)
+	if errors.Is(err, ErrNotFound) {
+		return models.ImportCreated, s.InsertConfiguration(configuration)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case mode == models.ImportFail:
+		return "", ErrAlreadyExists
+	case mode == models.ImportSkip:
+		return models.ImportSkipped, nil
+	case current.Value == configuration.Value && (configuration.Type == "" || configuration.Type == current.Type):
+		// overwriting with the same value would only add a revision
+		return models.ImportSkipped, nil
+	}
+
+	configuration.Id = current.Id
+	return models.ImportUpdated, s.UpdateConfiguration(configuration)
+}
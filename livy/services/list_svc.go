@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"livy/livy/models"
+)
+
+// MaxListLimit caps the number of configurations returned by a single call
+const MaxListLimit = 1000
+
+// listCursor is the position encoded in the cursors of ListConfiguration
+type listCursor struct {
+	Sort    string `json:"sort"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func encodeCursor(sort string, last models.Configuration) string {
+	data, _ := json.Marshal(listCursor{Sort: sort, Name: last.ConfigName, Version: last.Version})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(sort, cursor string) (*models.Configuration, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+
+	position := listCursor{}
+	err = json.Unmarshal(data, &position)
+	if err != nil || position.Name == "" {
+		return nil, fmt.Errorf("%w: invalid cursor", ErrValidation)
+	}
+	if position.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was made for sort %q", ErrValidation, position.Sort)
+	}
+
+	return &models.Configuration{ConfigName: position.Name, Version: position.Version}, nil
+}
+
+// ListConfiguration returns a page of the configurations matching query and
+// the cursor of the next page, empty on the last one. The page starts after
+// cursor, which only works with the sort it was returned for.
+func (s *LivySvc) ListConfiguration(query models.ConfigurationQuery, cursor string) ([]models.Configuration, string, error) {
+	if query.Limit < 1 || query.Limit > MaxListLimit {
+		return []models.Configuration{}, "", fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxListLimit)
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = models.SortName
+	case models.SortName, models.SortNameDesc, models.SortVersion, models.SortVersionDesc:
+	default:
+		return []models.Configuration{}, "", fmt.Errorf("%w: sort must be one of %s, %s, %s or %s", ErrValidation,
+			models.SortName, models.SortNameDesc, models.SortVersion, models.SortVersionDesc)
+	}
+
+	if cursor != "" {
+		after, err := decodeCursor(query.Sort, cursor)
+		if err != nil {
+			return []models.Configuration{}, "", err
+		}
+		query.After = after
+	}
+
+	_, err := s.db.GetNamespace(s.ctx, query.Namespace)
+	if err != nil {
+		return []models.Configuration{}, "", err
+	}
+
+	// one more row tells whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	configurations, err := s.db.ListConfiguration(s.ctx, query)
+	if err != nil {
+		return []models.Configuration{}, "", err
+	}
+
+	if len(configurations) <= limit {
+		return configurations, "", nil
+	}
+
+	configurations = configurations[:limit]
+	return configurations, encodeCursor(query.Sort, configurations[limit-1]), nil
+}
+
+// listAll returns every configuration matching query, sorted by name, reading
+// them MaxListLimit at a time
+func (s *LivySvc) listAll(query models.ConfigurationQuery) ([]models.Configuration, error) {
+	query.Sort = models.SortName
+	query.Limit = MaxListLimit
+
+	configurations := []models.Configuration{}
+	for {
+		page, err := s.db.ListConfiguration(s.ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		configurations = append(configurations, page...)
+
+		if len(page) < query.Limit {
+			return configurations, nil
+		}
+		query.After = &page[len(page)-1]
+	}
+}
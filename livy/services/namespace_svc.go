@@ -0,0 +1,35 @@
+package services
+
+import (
+	"fmt"
+	"livy/livy/models"
+	"regexp"
+)
+
+// namePattern restricts namespace and environment names
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (s *LivySvc) GetAllNamespace() ([]models.Namespace, error) {
+	return s.db.GetAllNamespace(s.ctx)
+}
+
+func (s *LivySvc) GetNamespace(name string) (models.Namespace, error) {
+	return s.db.GetNamespace(s.ctx, name)
+}
+
+func (s *LivySvc) InsertNamespace(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: namespace must be lowercase letters, digits, '-' or '_'", ErrValidation)
+	}
+
+	return s.db.InsertNamespace(s.ctx, name)
+}
+
+// DeleteNamespace removes an empty namespace, the default namespace is kept
+func (s *LivySvc) DeleteNamespace(name string) error {
+	if name == models.DefaultNamespace {
+		return fmt.Errorf("%w: the default namespace can't be deleted", ErrConflict)
+	}
+
+	return s.db.DeleteNamespace(s.ctx, name)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"livy/livy/formats"
+	"livy/livy/models"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL names the schema being compiled, it only shows up in errors
+const schemaURL = "livy://schema.json"
+
+func (s *LivySvc) GetAllSchema(namespace string) ([]models.Schema, error) {
+	_, err := s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return []models.Schema{}, err
+	}
+
+	return s.db.GetAllSchema(s.ctx, namespace)
+}
+
+func (s *LivySvc) GetSchema(namespace, configname string) (models.Schema, error) {
+	return s.db.GetSchema(s.ctx, namespace, configname)
+}
+
+// UpsertSchema sets the JSON Schema of a configuration name and reports whether
+// it was created. Values already stored are checked on their next change.
+func (s *LivySvc) UpsertSchema(schema models.Schema) (bool, error) {
+	err := validateConfigName(schema.ConfigName)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = compileSchema(schema.Schema)
+	if err != nil {
+		return false, err
+	}
+
+	return s.db.UpsertSchema(s.ctx, schema)
+}
+
+func (s *LivySvc) DeleteSchema(namespace, configname string) error {
+	return s.db.DeleteSchema(s.ctx, namespace, configname)
+}
+
+// schemaValue returns the value of configuration as a schema sees it. Json
+// values are decoded, the others are the JSON value of their type, a string
+// value is a JSON string.
+func schemaValue(configuration models.Configuration) (interface{}, error) {
+	if configuration.Type != models.TypeJSON {
+		return formats.Value(configuration.Type, configuration.Value), nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(configuration.Value))
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err == nil && decoder.More() {
+		err = fmt.Errorf("unexpected data after the json value")
+	}
+
+	return value, err
+}
+
+// validateSchema checks the value of configuration against the schema of its
+// name, names without a schema accept any value
+func (s *LivySvc) validateSchema(configuration models.Configuration) error {
+	schema, err := s.db.GetSchema(s.ctx, configuration.Namespace, configuration.ConfigName)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	compiled, err := compileSchema(schema.Schema)
+	if err != nil {
+		return err
+	}
+
+	value, err := schemaValue(configuration)
+	if err != nil {
+		return &ValidationError{
+			ConfigName: configuration.ConfigName,
+			Fields:     []FieldError{{Message: "invalid json: " + err.Error()}},
+		}
+	}
+
+	err = compiled.Validate(value)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return &ValidationError{
+			ConfigName: configuration.ConfigName,
+			Fields:     fieldErrors(validationErr, nil),
+		}
+	}
+
+	return err
+}
+
+// compileSchema parses document, references to other documents are refused so
+// a schema can't make Livy read files or call other hosts
+func compileSchema(document []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("can't load %s, external references are not allowed", url)
+	}
+
+	err := compiler.AddResource(schemaURL, bytes.NewReader(document))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid schema: %v", ErrValidation, err)
+	}
+
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid schema: %v", ErrValidation, err)
+	}
+
+	return schema, nil
+}
+
+// fieldErrors flattens the tree of err into the errors at its leaves, they
+// are the ones pointing at a field
+func fieldErrors(err *jsonschema.ValidationError, fields []FieldError) []FieldError {
+	if len(err.Causes) == 0 {
+		return append(fields, FieldError{
+			Field:   err.InstanceLocation,
+			Message: err.Message,
+		})
+	}
+
+	for _, cause := range err.Causes {
+		fields = fieldErrors(cause, fields)
+	}
+
+	return fields
+}
@@ -15,4 +15,13 @@ func NewLivySvc(ctx context.Context,db storages.LivyRepo) *LivySvc {
 		db: db,
 		ctx: ctx,
 	}
-}
\ No newline at end of file
+}
+
+// WithActor returns a copy of the service attributing its changes to actor in
+// the configuration history
+func (s *LivySvc) WithActor(actor string) *LivySvc {
+	return &LivySvc{
+		db:  s.db,
+		ctx: storages.WithActor(s.ctx, actor),
+	}
+}
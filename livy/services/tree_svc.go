@@ -0,0 +1,177 @@
+package services
+
+import (
+	"fmt"
+	"livy/livy/formats"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"strings"
+)
+
+// KeySeparator splits configuration names into the segments of their path,
+// the same that joins the keys of nested files
+const KeySeparator = formats.KeySeparator
+
+// splitKeyPath returns the segments of path, none of them can be empty
+func splitKeyPath(path string) ([]string, error) {
+	segments := strings.Split(path, KeySeparator)
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("%w: key path %q has an empty segment", ErrValidation, path)
+		}
+	}
+
+	return segments, nil
+}
+
+// inSubtree reports whether configname is path or below it
+func inSubtree(path, configname string) bool {
+	return configname == path || strings.HasPrefix(configname, path+KeySeparator)
+}
+
+// subtree returns the configurations of environment in the subtree of path,
+// sorted by name
+func (s *LivySvc) subtree(namespace, environment, path string) ([]models.Configuration, error) {
+	listed, err := s.listAll(models.ConfigurationQuery{
+		Namespace:   namespace,
+		Environment: environment,
+		Prefix:      path,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configurations := []models.Configuration{}
+	for _, configuration := range listed {
+		// the prefix also matches siblings like payments.gatewayv2
+		if inSubtree(path, configuration.ConfigName) {
+			configurations = append(configurations, configuration)
+		}
+	}
+
+	return configurations, nil
+}
+
+// resolveSubtree returns the configurations of the subtree of path by name,
+// the ones of environment override the ones of the base environment
+func (s *LivySvc) resolveSubtree(namespace, environment, path string) (map[string]models.Configuration, error) {
+	_, err := splitKeyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.GetNamespace(s.ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	environments := []string{models.BaseEnvironment}
+	if environment != models.BaseEnvironment {
+		environments = append(environments, environment)
+	}
+
+	resolved := map[string]models.Configuration{}
+	for _, environment := range environments {
+		configurations, err := s.subtree(namespace, environment, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, configuration := range configurations {
+			resolved[configuration.ConfigName] = configuration
+		}
+	}
+
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("key path %s: %w", path, ErrNotFound)
+	}
+
+	return resolved, nil
+}
+
+// GetFlatConfiguration returns the values of the subtree of path in
+// environment by full name, decoded according to their type. Values of the
+// base environment fill in for the ones environment doesn't override.
+func (s *LivySvc) GetFlatConfiguration(namespace, environment, path string) (map[string]interface{}, error) {
+	resolved, err := s.resolveSubtree(namespace, environment, path)
+	if err != nil {
+		return nil, err
+	}
+
+	flat := map[string]interface{}{}
+	for configname, configuration := range resolved {
+		flat[configname] = formats.Value(configuration.Type, configuration.Value)
+	}
+
+	return flat, nil
+}
+
+// GetConfigurationTree returns the subtree of path in environment as nested
+// objects, one level per segment below path, with the values decoded like
+// GetFlatConfiguration. A name that is both a value and the parent of others
+// keeps its value under the empty key, path itself is returned as a bare
+// value when it has no children.
+func (s *LivySvc) GetConfigurationTree(namespace, environment, path string) (interface{}, error) {
+	resolved, err := s.resolveSubtree(namespace, environment, path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []formats.Entry{}
+	for configname, configuration := range resolved {
+		// names are relative to path, path itself has the empty name
+		name := ""
+		if configname != path {
+			name = strings.TrimPrefix(configname, path+KeySeparator)
+		}
+		entries = append(entries, formats.Entry{Name: name, Value: configuration.Value, Type: configuration.Type})
+	}
+
+	root := formats.Nest(entries)
+	if leaf, ok := root[""]; ok && len(root) == 1 {
+		return leaf, nil
+	}
+
+	return root, nil
+}
+
+// DeleteConfigurationTree deletes every configuration of environment in the
+// subtree of path, all of them or none, and returns their names. The base
+// environment values stay when environment is an override.
+func (s *LivySvc) DeleteConfigurationTree(namespace, environment, path string) ([]string, error) {
+	_, err := splitKeyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	err = s.db.Atomic(s.ctx, func(repo storages.LivyRepo) error {
+		deleted = []string{}
+		tx := &LivySvc{
+			db:  repo,
+			ctx: s.ctx,
+		}
+
+		configurations, err := tx.subtree(namespace, environment, path)
+		if err != nil {
+			return err
+		}
+		if len(configurations) == 0 {
+			return fmt.Errorf("key path %s: %w", path, ErrNotFound)
+		}
+
+		for _, configuration := range configurations {
+			err = tx.DeleteConfigurationByName(namespace, environment, configuration.ConfigName)
+			if err != nil {
+				return fmt.Errorf("delete %s: %w", configuration.ConfigName, err)
+			}
+			deleted = append(deleted, configuration.ConfigName)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
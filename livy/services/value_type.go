@@ -0,0 +1,45 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"livy/livy/models"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// validateValue checks that value can be decoded as valueType
+func validateValue(valueType, value string) error {
+	var err error
+
+	switch valueType {
+	case models.TypeString:
+	case models.TypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case models.TypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case models.TypeBool:
+		_, err = strconv.ParseBool(value)
+	case models.TypeDuration:
+		_, err = time.ParseDuration(value)
+	case models.TypeJSON:
+		if !json.Valid([]byte(value)) {
+			err = fmt.Errorf("invalid json")
+		}
+	case models.TypeURL:
+		var u *url.URL
+		u, err = url.Parse(value)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = fmt.Errorf("missing scheme or host")
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrValidation, valueType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: value %q is not a valid %s", ErrValidation, value, valueType)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"livy/livy/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		valueType     string
+		value         string
+		expectedError bool
+	}{
+		{models.TypeString, "anything goes", false},
+		{models.TypeString, "", false},
+		{models.TypeInt, "42", false},
+		{models.TypeInt, "-7", false},
+		{models.TypeInt, "abc", true},
+		{models.TypeInt, "4.2", true},
+		{models.TypeFloat, "4.2", false},
+		{models.TypeFloat, "1e3", false},
+		{models.TypeFloat, "four", true},
+		{models.TypeBool, "true", false},
+		{models.TypeBool, "0", false},
+		{models.TypeBool, "yes", true},
+		{models.TypeDuration, "1m30s", false},
+		{models.TypeDuration, "90", true},
+		{models.TypeJSON, `{"a":[1,2]}`, false},
+		{models.TypeJSON, `{"a":`, true},
+		{models.TypeURL, "https://example.com/path", false},
+		{models.TypeURL, "example.com", true},
+		{models.TypeURL, "/relative", true},
+		{"uuid", "value", true},
+	}
+
+	for _, tc := range tests {
+		err := validateValue(tc.valueType, tc.value)
+		if tc.expectedError {
+			assert.ErrorIs(t, err, ErrValidation, "%s %q", tc.valueType, tc.value)
+		} else {
+			assert.NoError(t, err, "%s %q", tc.valueType, tc.value)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package storages
+
+import "errors"
+
+// Errors returned by every LivyRepo implementation, callers check them with
+// errors.Is.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrConflict      = errors.New("conflict")
+	ErrStaleVersion  = errors.New("stale version")
+)
@@ -0,0 +1,73 @@
+package storages
+
+import (
+	"context"
+	"livy/livy/models"
+	"time"
+)
+
+// UnknownActor is recorded for changes made without WithActor
+const UnknownActor = "unknown"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that attributes the changes made with it to
+// actor in the configuration history
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor set by WithActor, UnknownActor when there is none
+func ActorFrom(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return UnknownActor
+	}
+
+	return actor
+}
+
+// Revisions returns the history entries of a change from before to after, nil
+// meaning the configuration didn't exist. A rename is recorded as a delete of
+// the old name and an insert of the new one. Revision numbers are left to the
+// backend.
+func Revisions(ctx context.Context, before, after *models.Configuration) []models.ConfigurationRevision {
+	if before != nil && after != nil && before.ConfigName != after.ConfigName {
+		return append(Revisions(ctx, before, nil), Revisions(ctx, nil, after)...)
+	}
+
+	revision := models.ConfigurationRevision{
+		Actor: ActorFrom(ctx),
+		// postgres keeps microseconds, truncate so every backend agrees
+		ChangedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	current := after
+	switch {
+	case before == nil:
+		revision.Action = models.ActionInsert
+	case after == nil:
+		revision.Action = models.ActionDelete
+		current = before
+	default:
+		revision.Action = models.ActionUpdate
+	}
+
+	if before != nil {
+		value := before.Value
+		revision.OldValue = &value
+	}
+	if after != nil {
+		value := after.Value
+		revision.NewValue = &value
+	}
+
+	revision.ConfigurationId = current.Id
+	revision.Namespace = current.Namespace
+	revision.Environment = current.Environment
+	revision.ConfigName = current.ConfigName
+	revision.Type = current.Type
+	revision.Version = current.Version
+
+	return []models.ConfigurationRevision{revision}
+}
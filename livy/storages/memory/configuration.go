@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+func (m *MemoryStorage) GetAllConfiguration(ctx context.Context, namespace, environment string) ([]models.Configuration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	configurations := []models.Configuration{}
+	for _, configuration := range m.configurations {
+		if configuration.Namespace == namespace && configuration.Environment == environment {
+			configurations = append(configurations, configuration)
+		}
+	}
+
+	return configurations, nil
+}
+
+func (m *MemoryStorage) ListConfiguration(ctx context.Context, query models.ConfigurationQuery) ([]models.Configuration, error) {
+	configurations, err := m.GetAllConfiguration(ctx, query.Namespace, query.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := strings.HasPrefix(query.Sort, "-")
+	byVersion := strings.TrimPrefix(query.Sort, "-") == models.SortVersion
+	// before reports whether a comes first in the order of the query
+	before := func(a, b models.Configuration) bool {
+		if byVersion && a.Version != b.Version {
+			return (a.Version < b.Version) != desc
+		}
+		if a.ConfigName == b.ConfigName {
+			return false
+		}
+		return (a.ConfigName < b.ConfigName) != desc
+	}
+
+	matching := []models.Configuration{}
+	for _, configuration := range configurations {
+		if !strings.HasPrefix(configuration.ConfigName, query.Prefix) || !strings.Contains(configuration.ConfigName, query.Contains) {
+			continue
+		}
+		if query.After != nil && !before(*query.After, configuration) {
+			continue
+		}
+		matching = append(matching, configuration)
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		return before(matching[i], matching[j])
+	})
+
+	if len(matching) > query.Limit {
+		matching = matching[:query.Limit]
+	}
+
+	return matching, nil
+}
+
+func (m *MemoryStorage) GetConfiguration(ctx context.Context, namespace, environment, configname string) (models.Configuration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexOf(namespace, environment, configname)
+	if i < 0 {
+		return models.Configuration{}, storages.ErrNotFound
+	}
+
+	return m.configurations[i], nil
+}
+
+func (m *MemoryStorage) GetConfigurationById(ctx context.Context, namespace, id string) (models.Configuration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, configuration := range m.configurations {
+		if configuration.Namespace == namespace && configuration.Id == id {
+			return configuration, nil
+		}
+	}
+
+	return models.Configuration{}, storages.ErrNotFound
+}
+
+func (m *MemoryStorage) InsertConfiguration(ctx context.Context, configuration models.Configuration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insert(ctx, configuration)
+}
+
+func (m *MemoryStorage) insert(ctx context.Context, configuration models.Configuration) error {
+	if m.namespaceIndexOf(configuration.Namespace) < 0 {
+		return storages.ErrNotFound
+	}
+	if m.indexOf(configuration.Namespace, configuration.Environment, configuration.ConfigName) >= 0 {
+		return storages.ErrAlreadyExists
+	}
+
+	configuration.Id = uuid.NewString()
+	configuration.Version = 1
+	m.configurations = append(m.configurations, configuration)
+	m.record(storages.Revisions(ctx, nil, &configuration))
+
+	return nil
+}
+
+func (m *MemoryStorage) UpdateConfiguration(ctx context.Context, configuration models.Configuration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.configurations {
+		if m.configurations[i].Id == configuration.Id && m.configurations[i].Namespace == configuration.Namespace {
+			return m.update(ctx, i, configuration)
+		}
+	}
+
+	return storages.ErrNotFound
+}
+
+func (m *MemoryStorage) UpsertConfiguration(ctx context.Context, configuration models.Configuration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(configuration.Namespace, configuration.Environment, configuration.ConfigName)
+	if i < 0 && configuration.Version != 0 {
+		// a version was expected, the configuration had to exist
+		return false, storages.ErrStaleVersion
+	}
+	if i < 0 {
+		err := m.insert(ctx, configuration)
+		return err == nil, err
+	}
+
+	update := m.configurations[i]
+	update.Value = configuration.Value
+	update.Type = configuration.Type
+	update.Version = configuration.Version
+	return false, m.update(ctx, i, update)
+}
+
+// update sets the name, value and type of the configuration at i if its
+// version is still the Version of configuration, or any version when zero.
+// Callers must hold the lock.
+func (m *MemoryStorage) update(ctx context.Context, i int, configuration models.Configuration) error {
+	if configuration.Version != 0 && configuration.Version != m.configurations[i].Version {
+		return storages.ErrStaleVersion
+	}
+	if other := m.indexOf(configuration.Namespace, m.configurations[i].Environment, configuration.ConfigName); other >= 0 && other != i {
+		return storages.ErrAlreadyExists
+	}
+
+	before := m.configurations[i]
+	m.configurations[i].ConfigName = configuration.ConfigName
+	m.configurations[i].Value = configuration.Value
+	m.configurations[i].Type = configuration.Type
+	m.configurations[i].Version++
+	m.record(storages.Revisions(ctx, &before, &m.configurations[i]))
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteConfiguration(ctx context.Context, namespace, id string) error {
+	return m.deleteWhere(ctx, func(configuration models.Configuration) bool {
+		return configuration.Namespace == namespace && configuration.Id == id
+	})
+}
+
+func (m *MemoryStorage) DeleteConfigurationByName(ctx context.Context, namespace, environment, configname string) error {
+	return m.deleteWhere(ctx, func(configuration models.Configuration) bool {
+		return configuration.Namespace == namespace && configuration.Environment == environment && configuration.ConfigName == configname
+	})
+}
+
+func (m *MemoryStorage) deleteWhere(ctx context.Context, match func(models.Configuration) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.configurations[:0]
+	for _, configuration := range m.configurations {
+		if !match(configuration) {
+			kept = append(kept, configuration)
+		} else {
+			m.record(storages.Revisions(ctx, &configuration, nil))
+		}
+	}
+
+	if len(kept) == len(m.configurations) {
+		return storages.ErrNotFound
+	}
+
+	m.configurations = kept
+	return nil
+}
+
+// indexOf returns the position of configname or -1, callers must hold the lock
+func (m *MemoryStorage) indexOf(namespace, environment, configname string) int {
+	for i, configuration := range m.configurations {
+		if configuration.Namespace == namespace && configuration.Environment == environment && configuration.ConfigName == configname {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sort"
+	"time"
+)
+
+func (m *MemoryStorage) GetConfigurationHistory(ctx context.Context, namespace, environment, configname string, limit, offset int) ([]models.ConfigurationRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revisions := []models.ConfigurationRevision{}
+	// history is in insertion order, walk it backwards for newest first
+	for i := len(m.history) - 1; i >= 0 && len(revisions) < limit; i-- {
+		revision := m.history[i]
+		if revision.Namespace != namespace || revision.Environment != environment || revision.ConfigName != configname {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (m *MemoryStorage) GetConfigurationRevision(ctx context.Context, namespace, environment, configname string, revision int) (models.ConfigurationRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, r := range m.history {
+		if r.Namespace == namespace && r.Environment == environment && r.ConfigName == configname && r.Revision == revision {
+			return r, nil
+		}
+	}
+
+	return models.ConfigurationRevision{}, storages.ErrNotFound
+}
+
+// record numbers and appends revisions to the history, callers must hold the lock
+func (m *MemoryStorage) record(revisions []models.ConfigurationRevision) {
+	for _, revision := range revisions {
+		revision.Revision = m.lastRevision(revision.Namespace, revision.Environment, revision.ConfigName) + 1
+		m.history = append(m.history, revision)
+	}
+}
+
+// lastRevision returns the newest revision number of configname, 0 when it
+// has no history. Callers must hold the lock.
+func (m *MemoryStorage) lastRevision(namespace, environment, configname string) int {
+	for i := len(m.history) - 1; i >= 0; i-- {
+		revision := m.history[i]
+		if revision.Namespace == namespace && revision.Environment == environment && revision.ConfigName == configname {
+			return revision.Revision
+		}
+	}
+
+	return 0
+}
+
+func (m *MemoryStorage) GetAllConfigurationAsOf(ctx context.Context, namespace, environment string, asOf time.Time) ([]models.Configuration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// history is in insertion order, the last revision seen wins
+	latest := map[string]models.ConfigurationRevision{}
+	for _, revision := range m.history {
+		if revision.Namespace == namespace && revision.Environment == environment && !revision.ChangedAt.After(asOf) {
+			latest[revision.ConfigName] = revision
+		}
+	}
+
+	configurations := []models.Configuration{}
+	for _, revision := range latest {
+		if revision.NewValue == nil {
+			continue
+		}
+		configurations = append(configurations, models.Configuration{
+			Id:          revision.ConfigurationId,
+			Namespace:   revision.Namespace,
+			Environment: revision.Environment,
+			ConfigName:  revision.ConfigName,
+			Value:       *revision.NewValue,
+			Type:        revision.Type,
+			Version:     revision.Version,
+		})
+	}
+	sort.Slice(configurations, func(i, j int) bool {
+		return configurations[i].ConfigName < configurations[j].ConfigName
+	})
+
+	return configurations, nil
+}
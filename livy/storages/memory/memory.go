@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"livy/livy/models"
+	"livy/livy/storages"
+)
+
+// MemoryStorage keeps every table in process memory. It implements
+// storages.LivyRepo so Livy can run without a database, and is safe for
+// concurrent use.
+type MemoryStorage struct {
+	mu             sync.RWMutex
+	versions       []models.DBVersion
+	namespaces     []models.Namespace
+	configurations []models.Configuration
+	schemas        []models.Schema
+	history        []models.ConfigurationRevision
+	// migrationLock holds a value while LockMigrations is held
+	migrationLock chan struct{}
+}
+
+// New returns a storage holding only the default namespace, the one the
+// migrations of the other storages create
+func New() *MemoryStorage {
+	m := &MemoryStorage{
+		migrationLock: make(chan struct{}, 1),
+	}
+	m.insertNamespace(models.DefaultNamespace)
+
+	return m
+}
+
+// Atomic runs fn on a copy of the storage and keeps the copy when fn succeeds.
+// Other callers wait until fn returns.
+func (m *MemoryStorage) Atomic(ctx context.Context, fn func(repo storages.LivyRepo) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &MemoryStorage{
+		versions:       append([]models.DBVersion{}, m.versions...),
+		namespaces:     append([]models.Namespace{}, m.namespaces...),
+		configurations: append([]models.Configuration{}, m.configurations...),
+		schemas:        append([]models.Schema{}, m.schemas...),
+		history:        append([]models.ConfigurationRevision{}, m.history...),
+	}
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	m.versions = tx.versions
+	m.namespaces = tx.namespaces
+	m.configurations = tx.configurations
+	m.schemas = tx.schemas
+	m.history = tx.history
+
+	return nil
+}
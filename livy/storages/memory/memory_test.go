@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"livy/livy/storages"
+	"livy/livy/storages/memory"
+	"livy/livy/storages/storagetest"
+	"testing"
+)
+
+func TestLivyRepo(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.LivyRepo {
+		return memory.New()
+	})
+}
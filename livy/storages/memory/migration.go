@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InitiateTable is a no-op, versions are always kept in memory
+func (m *MemoryStorage) InitiateTable(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryStorage) GetDBVersion(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dbversion := 0
+	for _, version := range m.versions {
+		if version.Version > dbversion {
+			dbversion = version.Version
+		}
+	}
+
+	return dbversion, nil
+}
+
+func (m *MemoryStorage) GetAllDBVersion(ctx context.Context) ([]models.DBVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := append([]models.DBVersion{}, m.versions...)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	return versions, nil
+}
+
+func (m *MemoryStorage) InsertDBVersion(ctx context.Context, version models.DBVersion) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	version.Id = uuid.NewString()
+	version.AppliedAt = time.Now().UTC()
+	m.versions = append(m.versions, version)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteDBVersion(ctx context.Context, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kept := m.versions[:0]
+	for _, applied := range m.versions {
+		if applied.Version != version {
+			kept = append(kept, applied)
+		}
+	}
+	if len(kept) == len(m.versions) {
+		return storages.ErrNotFound
+	}
+	m.versions = kept
+
+	return nil
+}
+
+// Dialect is empty, the memory storage always has the latest schema and only
+// records the versions
+func (m *MemoryStorage) Dialect() string {
+	return ""
+}
+
+func (m *MemoryStorage) ExecMigration(ctx context.Context, statements string) error {
+	return nil
+}
+
+// LockMigrations lets a single caller migrate at a time
+func (m *MemoryStorage) LockMigrations(ctx context.Context) (func() error, error) {
+	select {
+	case m.migrationLock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	unlock := func() error {
+		<-m.migrationLock
+		return nil
+	}
+
+	return unlock, nil
+}
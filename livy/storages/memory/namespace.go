@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+func (m *MemoryStorage) GetAllNamespace(ctx context.Context) ([]models.Namespace, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	namespaces := make([]models.Namespace, len(m.namespaces))
+	copy(namespaces, m.namespaces)
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+
+	return namespaces, nil
+}
+
+func (m *MemoryStorage) GetNamespace(ctx context.Context, name string) (models.Namespace, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.namespaceIndexOf(name)
+	if i < 0 {
+		return models.Namespace{}, storages.ErrNotFound
+	}
+
+	return m.namespaces[i], nil
+}
+
+func (m *MemoryStorage) InsertNamespace(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insertNamespace(name)
+}
+
+func (m *MemoryStorage) insertNamespace(name string) error {
+	if m.namespaceIndexOf(name) >= 0 {
+		return storages.ErrAlreadyExists
+	}
+
+	m.namespaces = append(m.namespaces, models.Namespace{
+		Id:   uuid.NewString(),
+		Name: name,
+	})
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteNamespace(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.namespaceIndexOf(name)
+	if i < 0 {
+		return storages.ErrNotFound
+	}
+
+	for _, configuration := range m.configurations {
+		if configuration.Namespace == name {
+			return storages.ErrConflict
+		}
+	}
+
+	m.namespaces = append(m.namespaces[:i], m.namespaces[i+1:]...)
+
+	schemas := m.schemas[:0]
+	for _, schema := range m.schemas {
+		if schema.Namespace != name {
+			schemas = append(schemas, schema)
+		}
+	}
+	m.schemas = schemas
+
+	return nil
+}
+
+// namespaceIndexOf returns the position of name or -1, callers must hold the lock
+func (m *MemoryStorage) namespaceIndexOf(name string) int {
+	for i, namespace := range m.namespaces {
+		if namespace.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
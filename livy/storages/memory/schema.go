@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+func (m *MemoryStorage) GetAllSchema(ctx context.Context, namespace string) ([]models.Schema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schemas := []models.Schema{}
+	for _, schema := range m.schemas {
+		if schema.Namespace == namespace {
+			schemas = append(schemas, schema)
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].ConfigName < schemas[j].ConfigName
+	})
+
+	return schemas, nil
+}
+
+func (m *MemoryStorage) GetSchema(ctx context.Context, namespace, configname string) (models.Schema, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.schemaIndexOf(namespace, configname)
+	if i < 0 {
+		return models.Schema{}, storages.ErrNotFound
+	}
+
+	return m.schemas[i], nil
+}
+
+func (m *MemoryStorage) UpsertSchema(ctx context.Context, schema models.Schema) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.namespaceIndexOf(schema.Namespace) < 0 {
+		return false, storages.ErrNotFound
+	}
+
+	i := m.schemaIndexOf(schema.Namespace, schema.ConfigName)
+	if i >= 0 {
+		m.schemas[i].Schema = schema.Schema
+		return false, nil
+	}
+
+	schema.Id = uuid.NewString()
+	m.schemas = append(m.schemas, schema)
+
+	return true, nil
+}
+
+func (m *MemoryStorage) DeleteSchema(ctx context.Context, namespace, configname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.schemaIndexOf(namespace, configname)
+	if i < 0 {
+		return storages.ErrNotFound
+	}
+
+	m.schemas = append(m.schemas[:i], m.schemas[i+1:]...)
+	return nil
+}
+
+// schemaIndexOf returns the position of the schema of configname or -1,
+// callers must hold the lock
+func (m *MemoryStorage) schemaIndexOf(namespace, configname string) int {
+	for i, schema := range m.schemas {
+		if schema.Namespace == namespace && schema.ConfigName == configname {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -2,15 +2,38 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"livy/livy/models"
+	"livy/livy/storages"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
-func (pg *PostgresWrapper)GetAllConfiguration(ctx context.Context)([]models.Configuration,error){
-	query := "SELECT * FROM configuration"
+// configurationColumns is the column order read by scanConfiguration
+const configurationColumns = "id, namespace, environment, configname, value, type, version"
 
-	rows, err := pg.GetData(ctx, query)
+func scanConfiguration(rows *sql.Rows) (models.Configuration, error) {
+	configuration := models.Configuration{}
+	err := rows.Scan(
+		&configuration.Id,
+		&configuration.Namespace,
+		&configuration.Environment,
+		&configuration.ConfigName,
+		&configuration.Value,
+		&configuration.Type,
+		&configuration.Version,
+	)
+
+	return configuration, err
+}
+
+func (pg *PostgresWrapper)GetAllConfiguration(ctx context.Context, namespace, environment string)([]models.Configuration,error){
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND environment = $2"
+
+	rows, err := pg.GetData(ctx, query, namespace, environment)
 	if err != nil {
 		return nil, err
 	}
@@ -20,12 +43,7 @@ func (pg *PostgresWrapper)GetAllConfiguration(ctx context.Context)([]models.Conf
 	configurations := []models.Configuration{}
 
 	for rows.Next(){
-		configuration := models.Configuration{}
-		err = rows.Scan(
-			&configuration.Id,
-			&configuration.ConfigName,
-			&configuration.Value,
-		)
+		configuration, err := scanConfiguration(rows)
 		if err != nil {
 			return []models.Configuration{}, err
 		}
@@ -35,49 +53,234 @@ func (pg *PostgresWrapper)GetAllConfiguration(ctx context.Context)([]models.Conf
 	return configurations, nil
 }
 
-func (pg *PostgresWrapper)GetConfiguration(ctx context.Context,configname string)(models.Configuration, error){
-	query := "SELECT * FROM configuration WHERE configname = '" + configname + "'"
+func (pg *PostgresWrapper) ListConfiguration(ctx context.Context, query models.ConfigurationQuery) ([]models.Configuration, error) {
+	conditions := []string{"namespace = $1", "environment = $2"}
+	args := []interface{}{query.Namespace, query.Environment}
+	// arg binds value and returns its placeholder
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if query.Prefix != "" {
+		conditions = append(conditions, "starts_with(configname, "+arg(query.Prefix)+")")
+	}
+	if query.Contains != "" {
+		conditions = append(conditions, "strpos(configname, "+arg(query.Contains)+") > 0")
+	}
+
+	// names compare byte by byte, like in the other storages
+	name := `configname COLLATE "C"`
+	direction, op := "", ">"
+	if strings.HasPrefix(query.Sort, "-") {
+		direction, op = " DESC", "<"
+	}
+
+	order := name + direction
+	switch strings.TrimPrefix(query.Sort, "-") {
+	case models.SortVersion:
+		order = "version" + direction + ", " + order
+		if query.After != nil {
+			version, configname := arg(query.After.Version), arg(query.After.ConfigName)
+			conditions = append(conditions, "(version "+op+" "+version+" OR (version = "+version+" AND "+name+" "+op+" "+configname+"))")
+		}
+	default:
+		if query.After != nil {
+			conditions = append(conditions, name+" "+op+" "+arg(query.After.ConfigName))
+		}
+	}
 
-	rows, err := pg.GetData(ctx, query)
+	statement := "SELECT " + configurationColumns + " FROM configuration WHERE " + strings.Join(conditions, " AND ") + " ORDER BY " + order + " LIMIT " + arg(query.Limit)
+
+	rows, err := pg.GetData(ctx, statement, args...)
 	if err != nil {
-		return models.Configuration{}, err
+		return nil, err
 	}
 
-	configuration := models.Configuration{}
-	if rows.Next(){
-		err = rows.Scan(&configuration.Id,&configuration.ConfigName,&configuration.Value)
+	defer rows.Close()
+
+	configurations := []models.Configuration{}
+
+	for rows.Next() {
+		configuration, err := scanConfiguration(rows)
 		if err != nil {
-			return models.Configuration{}, err
+			return []models.Configuration{}, err
 		}
+		configurations = append(configurations, configuration)
+	}
+
+	return configurations, nil
+}
+
+func (pg *PostgresWrapper)GetConfiguration(ctx context.Context, namespace, environment, configname string)(models.Configuration, error){
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND environment = $2 AND configname = $3"
+
+	return pg.getConfiguration(ctx, query, namespace, environment, configname)
+}
+
+func (pg *PostgresWrapper) GetConfigurationById(ctx context.Context, namespace, id string) (models.Configuration, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return models.Configuration{}, storages.ErrNotFound
+	}
+
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND id = $2"
+
+	return pg.getConfiguration(ctx, query, namespace, id)
+}
+
+func (pg *PostgresWrapper) getConfiguration(ctx context.Context, query string, args ...interface{}) (models.Configuration, error) {
+	rows, err := pg.GetData(ctx, query, args...)
+	if err != nil {
+		return models.Configuration{}, err
 	}
 
-	return configuration, nil
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Configuration{}, storages.ErrNotFound
+	}
+
+	return scanConfiguration(rows)
+}
+
+func (pg *PostgresWrapper)InsertConfiguration(ctx context.Context, configuration models.Configuration) error{
+	return pg.WithTx(ctx, func(tx *PostgresWrapper) error {
+		return tx.insertConfiguration(ctx, configuration)
+	})
 }
 
-func (pg *PostgresWrapper)InsertConfiguration(ctx context.Context, configname,value string) error{
+func (pg *PostgresWrapper) insertConfiguration(ctx context.Context, configuration models.Configuration) error {
 	query := `
 		INSERT INTO configuration 
-		(id, configname, value)
+		(id, namespace, environment, configname, value, type, version)
 		VALUES
-		($1,$2,$3)
+		($1,$2,$3,$4,$5,$6,$7)
 	`
-	id := uuid.NewString()
-	_, err := pg.InsertData(ctx, query,id, configname, value)
+	configuration.Id = uuid.NewString()
+	configuration.Version = 1
+	_, err := pg.InsertData(ctx, query, configuration.Id, configuration.Namespace, configuration.Environment, configuration.ConfigName, configuration.Value, configuration.Type, configuration.Version)
+	if isUniqueViolation(err) {
+		return storages.ErrAlreadyExists
+	}
+	if isForeignKeyViolation(err) {
+		return storages.ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
 
-	return nil 
+	return pg.insertRevisions(ctx, storages.Revisions(ctx, nil, &configuration))
+}
+
+func (pg *PostgresWrapper)UpdateConfiguration(ctx context.Context, configuration models.Configuration) error{
+	if _, err := uuid.Parse(configuration.Id); err != nil {
+		return storages.ErrNotFound
+	}
+
+	return pg.WithTx(ctx, func(tx *PostgresWrapper) error {
+		query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND id = $2 FOR UPDATE"
+		before, err := tx.getConfiguration(ctx, query, configuration.Namespace, configuration.Id)
+		if err != nil {
+			return err
+		}
+
+		return tx.updateConfiguration(ctx, before, configuration)
+	})
 }
 
-func (pg *PostgresWrapper)UpdateConfiguration(ctx context.Context, id, configname,value string) error{
-	query := "UPDATE configuration SET configname = $1, value = $2  WHERE id = $3"
+// updateConfiguration sets the name, value and type of the locked row before if
+// its version is still the Version of configuration, or any version when zero
+func (pg *PostgresWrapper) updateConfiguration(ctx context.Context, before, configuration models.Configuration) error {
+	query := "UPDATE configuration SET configname = $1, value = $2, type = $3, version = version + 1 WHERE id = $4 AND version = $5"
 
-	_, err := pg.UpdateData(ctx, query, configname, value, id)
+	expected := configuration.Version
+	if expected == 0 {
+		expected = before.Version
+	}
+
+	updated, err := pg.UpdateData(ctx, query, configuration.ConfigName, configuration.Value, configuration.Type, before.Id, expected)
+	if isUniqueViolation(err) {
+		return storages.ErrAlreadyExists
+	}
 	if err != nil {
 		return err
 	}
+	if updated == 0 {
+		return storages.ErrStaleVersion
+	}
+
+	after := before
+	after.ConfigName = configuration.ConfigName
+	after.Value = configuration.Value
+	after.Type = configuration.Type
+	after.Version = expected + 1
+
+	return pg.insertRevisions(ctx, storages.Revisions(ctx, &before, &after))
+}
+
+func (pg *PostgresWrapper) UpsertConfiguration(ctx context.Context, configuration models.Configuration) (bool, error) {
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND environment = $2 AND configname = $3 FOR UPDATE"
+
+	for {
+		created := false
+		err := pg.WithTx(ctx, func(tx *PostgresWrapper) error {
+			// a retried attempt may find the row an earlier one didn't
+			created = false
+			before, err := tx.getConfiguration(ctx, query, configuration.Namespace, configuration.Environment, configuration.ConfigName)
+			if errors.Is(err, storages.ErrNotFound) && configuration.Version == 0 {
+				created = true
+				return tx.insertConfiguration(ctx, configuration)
+			}
+			if errors.Is(err, storages.ErrNotFound) {
+				// a version was expected, the configuration had to exist
+				return storages.ErrStaleVersion
+			}
+			if err != nil {
+				return err
+			}
+
+			update := before
+			update.Value = configuration.Value
+			update.Type = configuration.Type
+			update.Version = configuration.Version
+			return tx.updateConfiguration(ctx, before, update)
+		})
+		// someone created it in between, update their row instead. Inside an
+		// outer transaction the failed insert aborted it, so give up.
+		if errors.Is(err, storages.ErrAlreadyExists) && created && pg.tx == nil {
+			continue
+		}
+
+		return created, err
+	}
+}
+
+func (pg *PostgresWrapper) DeleteConfiguration(ctx context.Context, namespace, id string) error {
+	// the id column is a UUID, anything else can't match a row
+	if _, err := uuid.Parse(id); err != nil {
+		return storages.ErrNotFound
+	}
+
+	query := "DELETE FROM configuration WHERE id = $1 AND namespace = $2 RETURNING " + configurationColumns
+
+	return pg.deleteConfiguration(ctx, query, id, namespace)
+}
+
+func (pg *PostgresWrapper) DeleteConfigurationByName(ctx context.Context, namespace, environment, configname string) error {
+	query := "DELETE FROM configuration WHERE namespace = $1 AND environment = $2 AND configname = $3 RETURNING " + configurationColumns
+
+	return pg.deleteConfiguration(ctx, query, namespace, environment, configname)
+}
+
+// deleteConfiguration runs a DELETE ... RETURNING query and records the deleted
+// row in the history
+func (pg *PostgresWrapper) deleteConfiguration(ctx context.Context, query string, args ...interface{}) error {
+	return pg.WithTx(ctx, func(tx *PostgresWrapper) error {
+		before, err := tx.getConfiguration(ctx, query, args...)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.insertRevisions(ctx, storages.Revisions(ctx, &before, nil))
+	})
 }
-	
\ No newline at end of file
@@ -0,0 +1,158 @@
+package postgres_test
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"livy/livy/storages/postgres"
+	"livy/livy/storages/storagetest"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLivyRepo runs the storage contract against a real database. It drops
+// the livy tables, so it only runs when LIVY_TEST_POSTGRES is set.
+func TestLivyRepo(t *testing.T) {
+	if os.Getenv("LIVY_TEST_POSTGRES") == "" {
+		t.Skip("LIVY_TEST_POSTGRES not set")
+	}
+
+	storagetest.Run(t, func(t *testing.T) storages.LivyRepo {
+		pg, err := postgres.New()
+		require.NoError(t, err)
+
+		_, err = pg.DeleteData(context.Background(), "DROP TABLE IF EXISTS configuration_history, configuration_schema, configuration, namespace, db_version")
+		require.NoError(t, err)
+
+		return pg
+	})
+}
+
+func TestParameterizedQueries(t *testing.T) {
+	ctx := context.Background()
+	malicious := "x' OR '1'='1"
+	configurationColumns := []string{"id", "namespace", "environment", "configname", "value", "type", "version"}
+
+	tests := []struct {
+		name        string
+		mockSetup   func(mock sqlmock.Sqlmock)
+		checkResult func(t *testing.T, pg *postgres.PostgresWrapper)
+	}{
+		{
+			name: "get configuration",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(configurationColumns).
+					AddRow("1", "default", "base", malicious, "10", "string", 1)
+				mock.ExpectQuery(`WHERE namespace = \$1 AND environment = \$2 AND configname = \$3$`).
+					WithArgs("default", "base", malicious).
+					WillReturnRows(rows)
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				configuration, err := pg.GetConfiguration(ctx, "default", "base", malicious)
+				require.NoError(t, err)
+				assert.Equal(t, malicious, configuration.ConfigName)
+			},
+		},
+		{
+			name: "get all configuration",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE namespace = \$1 AND environment = \$2$`).
+					WithArgs(malicious, malicious).
+					WillReturnRows(sqlmock.NewRows(configurationColumns))
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				configurations, err := pg.GetAllConfiguration(ctx, malicious, malicious)
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+			},
+		},
+		{
+			name: "list configuration",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`AND starts_with\(configname, \$3\) AND strpos\(configname, \$4\) > 0 ` +
+					`AND \(version < \$5 OR \(version = \$5 AND configname COLLATE "C" < \$6\)\) ` +
+					`ORDER BY version DESC, configname COLLATE "C" DESC LIMIT \$7$`).
+					WithArgs("default", "base", malicious, "%", 2, malicious, 10).
+					WillReturnRows(sqlmock.NewRows(configurationColumns))
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				configurations, err := pg.ListConfiguration(ctx, models.ConfigurationQuery{
+					Namespace:   "default",
+					Environment: "base",
+					Prefix:      malicious,
+					Contains:    "%",
+					Sort:        models.SortVersionDesc,
+					After:       &models.Configuration{ConfigName: malicious, Version: 2},
+					Limit:       10,
+				})
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+			},
+		},
+		{
+			name:      "get configuration by invalid id",
+			mockSetup: func(mock sqlmock.Sqlmock) {},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				_, err := pg.GetConfigurationById(ctx, "default", malicious)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "get namespace",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE name = \$1$`).
+					WithArgs(malicious).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				_, err := pg.GetNamespace(ctx, malicious)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "get schema",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE namespace = \$1 AND configname = \$2$`).
+					WithArgs("default", malicious).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "namespace", "configname", "schema"}))
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				_, err := pg.GetSchema(ctx, "default", malicious)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "get configuration history",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE namespace = \$1 AND environment = \$2 AND configname = \$3\s+ORDER BY revision DESC\s+LIMIT \$4 OFFSET \$5`).
+					WithArgs("default", "base", malicious, 10, 0).
+					WillReturnRows(sqlmock.NewRows(nil))
+			},
+			checkResult: func(t *testing.T, pg *postgres.PostgresWrapper) {
+				revisions, err := pg.GetConfigurationHistory(ctx, "default", "base", malicious, 10, 0)
+				require.NoError(t, err)
+				assert.Empty(t, revisions)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pg, mock, cleanup := setupMock(t)
+			defer cleanup()
+
+			tc.mockSetup(mock)
+
+			tc.checkResult(t, pg)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
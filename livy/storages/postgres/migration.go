@@ -2,6 +2,11 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,19 +21,61 @@ func (pg *PostgresWrapper)InitiateTable(ctx context.Context) error{
 		return err
 	}
 
-	err = pg.InsertDBVersion(ctx, 1)
+	// older releases only recorded the version
+	query := `
+		ALTER TABLE db_version
+		ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	`
+	_, err = pg.UpdateData(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+// dbVersionColumns returns the columns of db_version, none when the table
+// doesn't exist yet
+func (pg *PostgresWrapper) dbVersionColumns(ctx context.Context) (map[string]bool, error) {
+	query := `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'db_version'
+	`
+
+	rows, err := pg.GetData(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		name := ""
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
 func (pg *PostgresWrapper)GetDBVersion(ctx context.Context) (int, error){
+	// a fresh database has no db_version table yet, report it as version 0
+	columns, err := pg.dbVersionColumns(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+
 	query := `
-		SELECT version 
+		SELECT COALESCE(MAX(version), 0)
 		FROM db_version
-		ORDER BY version DESC
-		LIMIT 1
 	`
 
 	rows, err := pg.GetData(ctx, query)
@@ -39,16 +86,72 @@ func (pg *PostgresWrapper)GetDBVersion(ctx context.Context) (int, error){
 	defer rows.Close()
 	dbversion := 0
 	for rows.Next() {
-		rows.Scan(&dbversion)
+		err = rows.Scan(&dbversion)
+		if err != nil {
+			return 0, err
+		}
 	}
 
 	return dbversion, nil
 }
-func (pg *PostgresWrapper)InsertDBVersion(ctx context.Context, version int) error{
-	query := "INSERT INTO db_version (id, version) VALUES ($1, $2)"
+
+// GetAllDBVersion reads db_version as it is, it is empty when the table is
+// missing and the columns older releases didn't have are left blank. Only
+// InitiateTable changes the table.
+func (pg *PostgresWrapper) GetAllDBVersion(ctx context.Context) ([]models.DBVersion, error) {
+	columns, err := pg.dbVersionColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []models.DBVersion{}, nil
+	}
+
+	fields := []string{"id", "version"}
+	for _, column := range []string{"name", "checksum"} {
+		if columns[column] {
+			fields = append(fields, column)
+		} else {
+			fields = append(fields, "'' AS "+column)
+		}
+	}
+	if columns["applied_at"] {
+		fields = append(fields, "applied_at")
+	} else {
+		fields = append(fields, "NULL::timestamptz AS applied_at")
+	}
+	query := "SELECT " + strings.Join(fields, ", ") + " FROM db_version ORDER BY version"
+
+	rows, err := pg.GetData(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	versions := []models.DBVersion{}
+
+	for rows.Next() {
+		version := models.DBVersion{}
+		appliedAt := sql.NullTime{}
+		err = rows.Scan(&version.Id, &version.Version, &version.Name, &version.Checksum, &appliedAt)
+		if err != nil {
+			return []models.DBVersion{}, err
+		}
+		if appliedAt.Valid {
+			version.AppliedAt = appliedAt.Time.UTC()
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+func (pg *PostgresWrapper)InsertDBVersion(ctx context.Context, version models.DBVersion) error{
+	query := "INSERT INTO db_version (id, version, name, checksum, applied_at) VALUES ($1, $2, $3, $4, $5)"
 	id := uuid.NewString()
 
-	_, err := pg.InsertData(ctx, query, id, version)
+	_, err := pg.InsertData(ctx, query, id, version.Version, version.Name, version.Checksum, time.Now().UTC())
 	if err != nil {
 		return err
 	}
@@ -56,16 +159,74 @@ func (pg *PostgresWrapper)InsertDBVersion(ctx context.Context, version int) erro
 	return nil
 }
 
-func (pg *PostgresWrapper) CreateConfigurationTable(ctx context.Context) error {
-	schema := `
-        id UUID PRIMARY KEY,
-		configname TEXT,
-		value TEXT
-    `
-	err := pg.CreateTable(ctx, "configuration", schema)
+func (pg *PostgresWrapper) DeleteDBVersion(ctx context.Context, version int) error {
+	query := "DELETE FROM db_version WHERE version = $1"
+
+	deleted, err := pg.DeleteData(ctx, query, version)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storages.ErrNotFound
+	}
+
+	return nil
+}
+
+func (pg *PostgresWrapper) Dialect() string {
+	return "postgres"
+}
+
+func (pg *PostgresWrapper) ExecMigration(ctx context.Context, statements string) error {
+	_, err := pg.UpdateData(ctx, statements)
 	if err != nil {
 		return err
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// migrationLockKey identifies the advisory lock taken by LockMigrations, every
+// livy instance sharing the database uses the same one
+const migrationLockKey = 8_127_340_519
+
+// lockPollInterval is how often LockMigrations tries the lock again
+const lockPollInterval = 100 * time.Millisecond
+
+// LockMigrations takes a session advisory lock, postgres releases it when the
+// connection is lost so a crashed instance doesn't keep it
+func (pg *PostgresWrapper) LockMigrations(ctx context.Context) (func() error, error) {
+	// the lock belongs to the connection, keep it until unlock
+	conn, err := pg.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		locked := false
+		err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&locked)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	unlock := func() error {
+		defer conn.Close()
+
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		return err
+	}
+
+	return unlock, nil
+}
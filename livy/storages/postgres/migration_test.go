@@ -0,0 +1,73 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockMigrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		mockSetup   func(mock sqlmock.Sqlmock)
+		checkResult func(t *testing.T, unlock func() error, err error)
+	}{
+		{
+			name:    "lock after waiting",
+			timeout: time.Second,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+				mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+				mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			checkResult: func(t *testing.T, unlock func() error, err error) {
+				require.NoError(t, err)
+				assert.NoError(t, unlock())
+			},
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT pg_try_advisory_lock\(\$1\)`).
+					WithArgs(sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+			},
+			checkResult: func(t *testing.T, unlock func() error, err error) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Nil(t, unlock)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pg, mock, cleanup := setupMock(t)
+			defer cleanup()
+
+			tc.mockSetup(mock)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			unlock, err := pg.LockMigrations(ctx)
+			tc.checkResult(t, unlock, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
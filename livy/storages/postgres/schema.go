@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"livy/livy/models"
+	"livy/livy/storages"
+
+	"github.com/google/uuid"
+)
+
+func scanSchema(rows *sql.Rows) (models.Schema, error) {
+	schema := models.Schema{}
+	document := ""
+	err := rows.Scan(&schema.Id, &schema.Namespace, &schema.ConfigName, &document)
+	schema.Schema = []byte(document)
+
+	return schema, err
+}
+
+func (pg *PostgresWrapper) GetAllSchema(ctx context.Context, namespace string) ([]models.Schema, error) {
+	query := "SELECT id, namespace, configname, schema FROM configuration_schema WHERE namespace = $1 ORDER BY configname"
+
+	rows, err := pg.GetData(ctx, query, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	schemas := []models.Schema{}
+
+	for rows.Next() {
+		schema, err := scanSchema(rows)
+		if err != nil {
+			return []models.Schema{}, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+func (pg *PostgresWrapper) GetSchema(ctx context.Context, namespace, configname string) (models.Schema, error) {
+	query := "SELECT id, namespace, configname, schema FROM configuration_schema WHERE namespace = $1 AND configname = $2"
+
+	rows, err := pg.GetData(ctx, query, namespace, configname)
+	if err != nil {
+		return models.Schema{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Schema{}, storages.ErrNotFound
+	}
+
+	return scanSchema(rows)
+}
+
+func (pg *PostgresWrapper) UpsertSchema(ctx context.Context, schema models.Schema) (bool, error) {
+	update := "UPDATE configuration_schema SET schema = $1 WHERE namespace = $2 AND configname = $3"
+	insert := "INSERT INTO configuration_schema (id, namespace, configname, schema) VALUES ($1, $2, $3, $4)"
+
+	for {
+		updated, err := pg.UpdateData(ctx, update, string(schema.Schema), schema.Namespace, schema.ConfigName)
+		if err != nil {
+			return false, err
+		}
+		if updated > 0 {
+			return false, nil
+		}
+
+		_, err = pg.InsertData(ctx, insert, uuid.NewString(), schema.Namespace, schema.ConfigName, string(schema.Schema))
+		if isForeignKeyViolation(err) {
+			return false, storages.ErrNotFound
+		}
+		if err == nil {
+			return true, nil
+		}
+		// someone created it in between, update their row instead
+		if !isUniqueViolation(err) {
+			return false, err
+		}
+	}
+}
+
+func (pg *PostgresWrapper) DeleteSchema(ctx context.Context, namespace, configname string) error {
+	query := "DELETE FROM configuration_schema WHERE namespace = $1 AND configname = $2"
+
+	deleted, err := pg.DeleteData(ctx, query, namespace, configname)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storages.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"livy/livy/storages"
+	"time"
+)
+
+// defaultTxRetries is how many times WithTx runs a transaction again after a
+// serialization failure or a deadlock, unless WithRetries says otherwise
+const defaultTxRetries = 3
+
+// conn is implemented by both *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (pg *PostgresWrapper) conn() conn {
+	if pg.tx != nil {
+		return pg.tx
+	}
+
+	return pg.db
+}
+
+type txConfig struct {
+	isolation sql.IsolationLevel
+	retries   int
+}
+
+// TxOption configures a transaction started by WithTx
+type TxOption func(*txConfig)
+
+// WithIsolation runs the transaction at level instead of the server default,
+// usually read committed
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(c *txConfig) {
+		c.isolation = level
+	}
+}
+
+// WithRetries sets how many times a transaction failing with a serialization
+// failure or a deadlock is run again, 0 disables retries
+func WithRetries(retries int) TxOption {
+	return func(c *txConfig) {
+		c.retries = retries
+	}
+}
+
+// WithTx runs fn with a copy of pg bound to a transaction. The transaction is
+// committed when fn returns nil and rolled back when it returns an error or
+// panics. Serialization failures and deadlocks run fn again in a new
+// transaction, so fn must not have side effects outside of tx.
+//
+// Calls made with a wrapper that is already in a transaction join it, their
+// options are ignored and they are never retried on their own.
+func (pg *PostgresWrapper) WithTx(ctx context.Context, fn func(tx *PostgresWrapper) error, opts ...TxOption) error {
+	if pg.tx != nil {
+		return fn(pg)
+	}
+
+	config := txConfig{
+		isolation: sql.LevelDefault,
+		retries:   defaultTxRetries,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := pg.runTx(ctx, config.isolation, fn)
+		if err == nil || !isRetryable(err) || attempt >= config.retries {
+			return err
+		}
+
+		// back off a little so the conflicting transaction can finish
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func (pg *PostgresWrapper) runTx(ctx context.Context, isolation sql.IsolationLevel, fn func(tx *PostgresWrapper) error) (err error) {
+	tx, err := pg.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(&PostgresWrapper{db: pg.db, tx: tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *PostgresWrapper) Atomic(ctx context.Context, fn func(repo storages.LivyRepo) error) error {
+	return pg.WithTx(ctx, func(tx *PostgresWrapper) error {
+		return fn(tx)
+	})
+}
@@ -0,0 +1,211 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"livy/livy/models"
+	"livy/livy/storages/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	query := "UPDATE users SET name = ? WHERE id = ?"
+	serializationFailure := &pq.Error{Code: "40001"}
+
+	tests := []struct {
+		name        string
+		opts        []postgres.TxOption
+		mockSetup   func(mock sqlmock.Sqlmock)
+		fn          func(attempt int) error
+		checkResult func(t *testing.T, attempts int, err error)
+	}{
+		{
+			name: "commit",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 1, attempts)
+			},
+		},
+		{
+			name: "rollback on error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectRollback()
+			},
+			fn: func(attempt int) error {
+				return sql.ErrNoRows
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				require.ErrorIs(t, err, sql.ErrNoRows)
+				assert.Equal(t, 1, attempts)
+			},
+		},
+		{
+			name: "retry serialization failure",
+			opts: []postgres.TxOption{postgres.WithIsolation(sql.LevelSerializable)},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnError(serializationFailure)
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, attempts)
+			},
+		},
+		{
+			name: "retry failed commit",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit().WillReturnError(serializationFailure)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 2, attempts)
+			},
+		},
+		{
+			name: "retries exhausted",
+			opts: []postgres.TxOption{postgres.WithRetries(1)},
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 2; i++ {
+					mock.ExpectBegin()
+					mock.ExpectExec("UPDATE users").WillReturnError(serializationFailure)
+					mock.ExpectRollback()
+				}
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				var pqErr *pq.Error
+				require.True(t, errors.As(err, &pqErr))
+				assert.Equal(t, pq.ErrorCode("40001"), pqErr.Code)
+				assert.Equal(t, 2, attempts)
+			},
+		},
+		{
+			name: "begin error",
+			mockSetup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, attempts int, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				assert.Contains(t, err.Error(), "failed to begin transaction")
+				assert.Equal(t, 0, attempts)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc // Capture range variable for parallel execution
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pg, mock, cleanup := setupMock(t)
+			defer cleanup()
+
+			tc.mockSetup(mock)
+
+			attempts := 0
+			err := pg.WithTx(ctx, func(tx *postgres.PostgresWrapper) error {
+				attempts++
+				_, err := tx.UpdateData(ctx, query, "Jane", 1)
+				if err != nil {
+					return err
+				}
+				if tc.fn != nil {
+					return tc.fn(attempts)
+				}
+				return nil
+			}, tc.opts...)
+
+			tc.checkResult(t, attempts, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithTxPanic(t *testing.T) {
+	pg, mock, cleanup := setupMock(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assert.PanicsWithValue(t, "boom", func() {
+		pg.WithTx(context.Background(), func(tx *postgres.PostgresWrapper) error {
+			panic("boom")
+		})
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxNested(t *testing.T) {
+	ctx := context.Background()
+	pg, mock, cleanup := setupMock(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := pg.WithTx(ctx, func(tx *postgres.PostgresWrapper) error {
+		return tx.WithTx(ctx, func(inner *postgres.PostgresWrapper) error {
+			_, err := inner.UpdateData(ctx, "UPDATE users SET name = ? WHERE id = ?", "Jane", 1)
+			return err
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUpsertRetry checks an attempt retried after a serialization failure
+// doesn't keep what the failed one reported
+func TestUpsertRetry(t *testing.T) {
+	ctx := context.Background()
+	pg, mock, cleanup := setupMock(t)
+	defer cleanup()
+
+	columns := []string{"id", "namespace", "environment", "configname", "value", "type", "version"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec("INSERT INTO configuration").WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("7d9f6c1e-4a8b-4c7e-9f3a-2b1d5e6f7a8b", "default", "base", "timeout", "10", "string", 1))
+	mock.ExpectExec("UPDATE configuration").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO configuration_history").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := pg.UpsertConfiguration(ctx, models.Configuration{
+		Namespace:   "default",
+		Environment: "base",
+		ConfigName:  "timeout",
+		Value:       "20",
+		Type:        "string",
+	})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
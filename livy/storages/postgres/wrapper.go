@@ -12,6 +12,8 @@ import (
 
 type PostgresWrapper struct {
 	db *sql.DB
+	// tx is set on the copies handed out by WithTx
+	tx *sql.Tx
 }
 
 func NewForTest(db *sql.DB) *PostgresWrapper {
@@ -43,12 +45,12 @@ func New() (*PostgresWrapper, error) {
 	}, nil
 }
 
-func (pg *PostgresWrapper) GetData(ctx context.Context, query string) (*sql.Rows, error) {
+func (pg *PostgresWrapper) GetData(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	if query == "" {
 		return nil, fmt.Errorf("query can't be empty")
 	}
 
-	return pg.db.QueryContext(ctx, query)
+	return pg.conn().QueryContext(ctx, query, args...)
 }
 
 func (pg *PostgresWrapper) InsertData(ctx context.Context, query string, args ...interface{}) (int64, error) {
@@ -56,9 +58,9 @@ func (pg *PostgresWrapper) InsertData(ctx context.Context, query string, args ..
 		return 0, fmt.Errorf("query can't be empty")
 	}
 
-	result, err := pg.db.ExecContext(ctx, query, args...)
+	result, err := pg.conn().ExecContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute query: %v", err)
+		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
 
 	insertedId, err := result.LastInsertId()
@@ -74,9 +76,9 @@ func (pg *PostgresWrapper) UpdateData(ctx context.Context, query string, args ..
 		return 0, fmt.Errorf("query can't be empty")
 	}
 
-	result, err := pg.db.ExecContext(ctx, query, args...)
+	result, err := pg.conn().ExecContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute update query: %v", err)
+		return 0, fmt.Errorf("failed to execute update query: %w", err)
 	}
 
 	rowAffected, err := result.RowsAffected()
@@ -92,9 +94,9 @@ func (pg *PostgresWrapper) DeleteData(ctx context.Context, query string, args ..
 		return 0, fmt.Errorf("query can't be empty")
 	}
 
-	result, err := pg.db.ExecContext(ctx, query, args...)
+	result, err := pg.conn().ExecContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute delete query: %v", err)
+		return 0, fmt.Errorf("failed to execute delete query: %w", err)
 	}
 
 	rowAffected, err := result.RowsAffected()
@@ -111,7 +113,7 @@ func (pg *PostgresWrapper) CreateTable(ctx context.Context, tablename, schema st
 	}
 
 	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s);", tablename, schema)
-	_, err := pg.db.ExecContext(ctx, query)
+	_, err := pg.conn().ExecContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %v", err)
 	}
@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// configurationColumns is the column order read by scanConfiguration
+const configurationColumns = "id, namespace, environment, configname, value, type, version"
+
+func scanConfiguration(rows *sql.Rows) (models.Configuration, error) {
+	configuration := models.Configuration{}
+	err := rows.Scan(
+		&configuration.Id,
+		&configuration.Namespace,
+		&configuration.Environment,
+		&configuration.ConfigName,
+		&configuration.Value,
+		&configuration.Type,
+		&configuration.Version,
+	)
+
+	return configuration, err
+}
+
+func (s *SqliteWrapper) GetAllConfiguration(ctx context.Context, namespace, environment string) ([]models.Configuration, error) {
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND environment = $2"
+
+	rows, err := s.GetData(ctx, query, namespace, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	configurations := []models.Configuration{}
+
+	for rows.Next() {
+		configuration, err := scanConfiguration(rows)
+		if err != nil {
+			return []models.Configuration{}, err
+		}
+		configurations = append(configurations, configuration)
+	}
+
+	return configurations, nil
+}
+
+func (s *SqliteWrapper) ListConfiguration(ctx context.Context, query models.ConfigurationQuery) ([]models.Configuration, error) {
+	conditions := []string{"namespace = $1", "environment = $2"}
+	args := []interface{}{query.Namespace, query.Environment}
+	// arg binds value and returns its placeholder
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if query.Prefix != "" {
+		prefix := arg(query.Prefix)
+		conditions = append(conditions, "substr(configname, 1, length("+prefix+")) = "+prefix)
+	}
+	if query.Contains != "" {
+		conditions = append(conditions, "instr(configname, "+arg(query.Contains)+") > 0")
+	}
+
+	// the default BINARY collation compares names byte by byte
+	name := "configname"
+	direction, op := "", ">"
+	if strings.HasPrefix(query.Sort, "-") {
+		direction, op = " DESC", "<"
+	}
+
+	order := name + direction
+	switch strings.TrimPrefix(query.Sort, "-") {
+	case models.SortVersion:
+		order = "version" + direction + ", " + order
+		if query.After != nil {
+			version, configname := arg(query.After.Version), arg(query.After.ConfigName)
+			conditions = append(conditions, "(version "+op+" "+version+" OR (version = "+version+" AND "+name+" "+op+" "+configname+"))")
+		}
+	default:
+		if query.After != nil {
+			conditions = append(conditions, name+" "+op+" "+arg(query.After.ConfigName))
+		}
+	}
+
+	statement := "SELECT " + configurationColumns + " FROM configuration WHERE " + strings.Join(conditions, " AND ") + " ORDER BY " + order + " LIMIT " + arg(query.Limit)
+
+	rows, err := s.GetData(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	configurations := []models.Configuration{}
+
+	for rows.Next() {
+		configuration, err := scanConfiguration(rows)
+		if err != nil {
+			return []models.Configuration{}, err
+		}
+		configurations = append(configurations, configuration)
+	}
+
+	return configurations, nil
+}
+
+func (s *SqliteWrapper) GetConfiguration(ctx context.Context, namespace, environment, configname string) (models.Configuration, error) {
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND environment = $2 AND configname = $3"
+
+	return s.getConfiguration(ctx, query, namespace, environment, configname)
+}
+
+func (s *SqliteWrapper) GetConfigurationById(ctx context.Context, namespace, id string) (models.Configuration, error) {
+	query := "SELECT " + configurationColumns + " FROM configuration WHERE namespace = $1 AND id = $2"
+
+	return s.getConfiguration(ctx, query, namespace, id)
+}
+
+func (s *SqliteWrapper) getConfiguration(ctx context.Context, query string, args ...interface{}) (models.Configuration, error) {
+	rows, err := s.GetData(ctx, query, args...)
+	if err != nil {
+		return models.Configuration{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Configuration{}, storages.ErrNotFound
+	}
+
+	return scanConfiguration(rows)
+}
+
+func (s *SqliteWrapper) InsertConfiguration(ctx context.Context, configuration models.Configuration) error {
+	return s.inTx(ctx, func(tx *SqliteWrapper) error {
+		return tx.insertConfiguration(ctx, configuration)
+	})
+}
+
+func (s *SqliteWrapper) insertConfiguration(ctx context.Context, configuration models.Configuration) error {
+	// sqlite can't add a foreign key to an existing table, check the namespace here
+	_, err := s.GetNamespace(ctx, configuration.Namespace)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO configuration
+		(id, namespace, environment, configname, value, type, version)
+		VALUES
+		($1,$2,$3,$4,$5,$6,$7)
+	`
+	configuration.Id = uuid.NewString()
+	configuration.Version = 1
+	_, err = s.InsertData(ctx, query, configuration.Id, configuration.Namespace, configuration.Environment, configuration.ConfigName, configuration.Value, configuration.Type, configuration.Version)
+	if isUniqueViolation(err) {
+		return storages.ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.insertRevisions(ctx, storages.Revisions(ctx, nil, &configuration))
+}
+
+func (s *SqliteWrapper) UpdateConfiguration(ctx context.Context, configuration models.Configuration) error {
+	return s.inTx(ctx, func(tx *SqliteWrapper) error {
+		before, err := tx.GetConfigurationById(ctx, configuration.Namespace, configuration.Id)
+		if err != nil {
+			return err
+		}
+
+		return tx.updateConfiguration(ctx, before, configuration)
+	})
+}
+
+// updateConfiguration sets the name, value and type of the row before if
+// its version is still the Version of configuration, or any version when zero
+func (s *SqliteWrapper) updateConfiguration(ctx context.Context, before, configuration models.Configuration) error {
+	query := "UPDATE configuration SET configname = $1, value = $2, type = $3, version = version + 1 WHERE id = $4 AND version = $5"
+
+	expected := configuration.Version
+	if expected == 0 {
+		expected = before.Version
+	}
+
+	updated, err := s.UpdateData(ctx, query, configuration.ConfigName, configuration.Value, configuration.Type, before.Id, expected)
+	if isUniqueViolation(err) {
+		return storages.ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return storages.ErrStaleVersion
+	}
+
+	after := before
+	after.ConfigName = configuration.ConfigName
+	after.Value = configuration.Value
+	after.Type = configuration.Type
+	after.Version = expected + 1
+
+	return s.insertRevisions(ctx, storages.Revisions(ctx, &before, &after))
+}
+
+// UpsertConfiguration doesn't need to retry like postgres, the single
+// connection serializes transactions
+func (s *SqliteWrapper) UpsertConfiguration(ctx context.Context, configuration models.Configuration) (bool, error) {
+	created := false
+	err := s.inTx(ctx, func(tx *SqliteWrapper) error {
+		before, err := tx.GetConfiguration(ctx, configuration.Namespace, configuration.Environment, configuration.ConfigName)
+		if errors.Is(err, storages.ErrNotFound) && configuration.Version == 0 {
+			created = true
+			return tx.insertConfiguration(ctx, configuration)
+		}
+		if errors.Is(err, storages.ErrNotFound) {
+			// a version was expected, the configuration had to exist
+			return storages.ErrStaleVersion
+		}
+		if err != nil {
+			return err
+		}
+
+		update := before
+		update.Value = configuration.Value
+		update.Type = configuration.Type
+		update.Version = configuration.Version
+		return tx.updateConfiguration(ctx, before, update)
+	})
+
+	return created, err
+}
+
+func (s *SqliteWrapper) DeleteConfiguration(ctx context.Context, namespace, id string) error {
+	query := "DELETE FROM configuration WHERE id = $1 AND namespace = $2 RETURNING " + configurationColumns
+
+	return s.deleteConfiguration(ctx, query, id, namespace)
+}
+
+func (s *SqliteWrapper) DeleteConfigurationByName(ctx context.Context, namespace, environment, configname string) error {
+	query := "DELETE FROM configuration WHERE namespace = $1 AND environment = $2 AND configname = $3 RETURNING " + configurationColumns
+
+	return s.deleteConfiguration(ctx, query, namespace, environment, configname)
+}
+
+// deleteConfiguration runs a DELETE ... RETURNING query and records the deleted
+// row in the history
+func (s *SqliteWrapper) deleteConfiguration(ctx context.Context, query string, args ...interface{}) error {
+	return s.inTx(ctx, func(tx *SqliteWrapper) error {
+		before, err := tx.getConfiguration(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		return tx.insertRevisions(ctx, storages.Revisions(ctx, &before, nil))
+	})
+}
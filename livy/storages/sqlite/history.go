@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// timeLayout has a fixed width so timestamps stored as text sort in time order
+const timeLayout = "2006-01-02T15:04:05.000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// revisionColumns is the column order read by scanRevision
+const revisionColumns = "COALESCE(configuration_id, ''), namespace, environment, configname, revision, action, old_value, new_value, type, COALESCE(version, 0), actor, changed_at"
+
+func scanRevision(rows *sql.Rows) (models.ConfigurationRevision, error) {
+	revision := models.ConfigurationRevision{}
+	changedAt := ""
+	err := rows.Scan(
+		&revision.ConfigurationId,
+		&revision.Namespace,
+		&revision.Environment,
+		&revision.ConfigName,
+		&revision.Revision,
+		&revision.Action,
+		&revision.OldValue,
+		&revision.NewValue,
+		&revision.Type,
+		&revision.Version,
+		&revision.Actor,
+		&changedAt,
+	)
+	if err != nil {
+		return revision, err
+	}
+
+	revision.ChangedAt, err = time.Parse(timeLayout, changedAt)
+
+	return revision, err
+}
+
+func (s *SqliteWrapper) GetConfigurationHistory(ctx context.Context, namespace, environment, configname string, limit, offset int) ([]models.ConfigurationRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM configuration_history
+		WHERE namespace = $1 AND environment = $2 AND configname = $3
+		ORDER BY revision DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := s.GetData(ctx, query, namespace, environment, configname, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := []models.ConfigurationRevision{}
+
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return []models.ConfigurationRevision{}, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (s *SqliteWrapper) GetConfigurationRevision(ctx context.Context, namespace, environment, configname string, revision int) (models.ConfigurationRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM configuration_history
+		WHERE namespace = $1 AND environment = $2 AND configname = $3 AND revision = $4
+	`
+
+	rows, err := s.GetData(ctx, query, namespace, environment, configname, revision)
+	if err != nil {
+		return models.ConfigurationRevision{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.ConfigurationRevision{}, storages.ErrNotFound
+	}
+
+	return scanRevision(rows)
+}
+
+func (s *SqliteWrapper) GetAllConfigurationAsOf(ctx context.Context, namespace, environment string, asOf time.Time) ([]models.Configuration, error) {
+	query := `
+		SELECT COALESCE(h.configuration_id, ''), h.namespace, h.environment, h.configname, h.new_value, h.type, COALESCE(h.version, 0)
+		FROM configuration_history h
+		WHERE h.namespace = $1 AND h.environment = $2 AND h.new_value IS NOT NULL
+		AND h.revision = (
+			SELECT MAX(revision) FROM configuration_history
+			WHERE namespace = h.namespace AND environment = h.environment AND configname = h.configname
+			AND changed_at <= $3
+		)
+		ORDER BY h.configname
+	`
+
+	rows, err := s.GetData(ctx, query, namespace, environment, formatTime(asOf))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	configurations := []models.Configuration{}
+
+	for rows.Next() {
+		configuration, err := scanConfiguration(rows)
+		if err != nil {
+			return []models.Configuration{}, err
+		}
+		configurations = append(configurations, configuration)
+	}
+
+	return configurations, nil
+}
+
+// insertRevisions appends revisions to the history, numbering each one after
+// the last revision of its configuration. Call it in the transaction of the
+// change.
+func (s *SqliteWrapper) insertRevisions(ctx context.Context, revisions []models.ConfigurationRevision) error {
+	query := `
+		INSERT INTO configuration_history
+		(id, namespace, environment, configname, revision, action, old_value, new_value, type, actor, changed_at, configuration_id, version)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(revision), 0) + 1, $5, $6, $7, $8, $9, $10, $11, $12
+		FROM configuration_history
+		WHERE namespace = $2 AND environment = $3 AND configname = $4
+	`
+
+	for _, revision := range revisions {
+		_, err := s.InsertData(ctx, query,
+			uuid.NewString(),
+			revision.Namespace,
+			revision.Environment,
+			revision.ConfigName,
+			revision.Action,
+			revision.OldValue,
+			revision.NewValue,
+			revision.Type,
+			revision.Actor,
+			formatTime(revision.ChangedAt),
+			revision.ConfigurationId,
+			revision.Version,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
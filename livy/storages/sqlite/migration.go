@@ -0,0 +1,281 @@
+package sqlite
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *SqliteWrapper) InitiateTable(ctx context.Context) error {
+	schema := `
+		id TEXT PRIMARY KEY,
+		version INTEGER
+	`
+	err := s.CreateTable(ctx, "db_version", schema)
+	if err != nil {
+		return err
+	}
+
+	// older releases only recorded the version
+	return s.addMissingColumns(ctx, "db_version",
+		"name TEXT NOT NULL DEFAULT ''",
+		"checksum TEXT NOT NULL DEFAULT ''",
+		"applied_at TEXT NOT NULL DEFAULT ''",
+	)
+}
+
+// addMissingColumns adds the columns of table it doesn't have yet, each given
+// as its definition starting with the name. sqlite has no ADD COLUMN IF NOT
+// EXISTS.
+func (s *SqliteWrapper) addMissingColumns(ctx context.Context, table string, columns ...string) error {
+	existing, err := s.columnsOf(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		name, _, _ := strings.Cut(column, " ")
+		if existing[name] {
+			continue
+		}
+
+		_, err = s.UpdateData(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// columnsOf returns the columns of table, none when it doesn't exist
+func (s *SqliteWrapper) columnsOf(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := s.GetData(ctx, "SELECT name FROM pragma_table_info($1)", table)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		name := ""
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+func (s *SqliteWrapper) GetDBVersion(ctx context.Context) (int, error) {
+	// a fresh database has no db_version table yet, report it as version 0
+	exists := 0
+	err := s.conn().QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'db_version'").Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	query := `
+		SELECT version
+		FROM db_version
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	rows, err := s.GetData(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+	dbversion := 0
+	for rows.Next() {
+		err = rows.Scan(&dbversion)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return dbversion, nil
+}
+
+// GetAllDBVersion reads db_version as it is, it is empty when the table is
+// missing and the columns older releases didn't have are left blank. Only
+// InitiateTable changes the table.
+func (s *SqliteWrapper) GetAllDBVersion(ctx context.Context) ([]models.DBVersion, error) {
+	columns, err := s.columnsOf(ctx, "db_version")
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []models.DBVersion{}, nil
+	}
+
+	fields := []string{"id", "version"}
+	for _, column := range []string{"name", "checksum", "applied_at"} {
+		if columns[column] {
+			fields = append(fields, column)
+		} else {
+			fields = append(fields, "'' AS "+column)
+		}
+	}
+	query := "SELECT " + strings.Join(fields, ", ") + " FROM db_version ORDER BY version"
+
+	rows, err := s.GetData(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	versions := []models.DBVersion{}
+
+	for rows.Next() {
+		version := models.DBVersion{}
+		appliedAt := ""
+		err = rows.Scan(&version.Id, &version.Version, &version.Name, &version.Checksum, &appliedAt)
+		if err != nil {
+			return []models.DBVersion{}, err
+		}
+		// rows of older releases have no time
+		if appliedAt != "" {
+			version.AppliedAt, err = time.Parse(timeLayout, appliedAt)
+			if err != nil {
+				return []models.DBVersion{}, err
+			}
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+func (s *SqliteWrapper) InsertDBVersion(ctx context.Context, version models.DBVersion) error {
+	query := "INSERT INTO db_version (id, version, name, checksum, applied_at) VALUES ($1, $2, $3, $4, $5)"
+	id := uuid.NewString()
+
+	_, err := s.InsertData(ctx, query, id, version.Version, version.Name, version.Checksum, formatTime(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SqliteWrapper) DeleteDBVersion(ctx context.Context, version int) error {
+	query := "DELETE FROM db_version WHERE version = $1"
+
+	deleted, err := s.DeleteData(ctx, query, version)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storages.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SqliteWrapper) Dialect() string {
+	return "sqlite"
+}
+
+func (s *SqliteWrapper) ExecMigration(ctx context.Context, statements string) error {
+	_, err := s.UpdateData(ctx, statements)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// lockPollInterval is how often LockMigrations tries the lock again
+const lockPollInterval = 100 * time.Millisecond
+
+// lockLease is how long the migration lock stays taken without being renewed,
+// the holder renews it every lockLease/3
+const lockLease = 30 * time.Second
+
+// LockMigrations takes the single row of migration_lock for a lease that is
+// renewed while the lock is held, other processes sharing the file wait until
+// it is deleted or expires. The lease of a process killed while migrating
+// expires, so the next one takes the lock over.
+func (s *SqliteWrapper) LockMigrations(ctx context.Context) (func() error, error) {
+	schema := `
+		name TEXT NOT NULL UNIQUE,
+		locked_at TEXT NOT NULL,
+		owner TEXT NOT NULL,
+		expires_at INTEGER NOT NULL
+	`
+	err := s.CreateTable(ctx, "migration_lock", schema)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := uuid.NewString()
+	query := `
+		INSERT INTO migration_lock (name, locked_at, owner, expires_at)
+		VALUES ('migrations', $1, $2, $3)
+		ON CONFLICT (name) DO UPDATE
+		SET locked_at = excluded.locked_at, owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE migration_lock.expires_at < $4
+	`
+	for {
+		now := time.Now()
+		taken, err := s.UpdateData(ctx, query, formatTime(now), owner, now.Add(lockLease).UnixNano(), now.UnixNano())
+		if err != nil {
+			return nil, err
+		}
+		if taken > 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(lockLease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				renew := "UPDATE migration_lock SET expires_at = $1 WHERE name = 'migrations' AND owner = $2"
+				_, err := s.UpdateData(context.Background(), renew, time.Now().Add(lockLease).UnixNano(), owner)
+				if err != nil {
+					log.Println("failed to renew the migration lock:", err)
+				}
+			}
+		}
+	}()
+
+	unlock := func() error {
+		close(stop)
+		<-stopped
+
+		_, err := s.DeleteData(context.Background(), "DELETE FROM migration_lock WHERE name = 'migrations' AND owner = $1", owner)
+		return err
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"livy/livy/models"
+	"livy/livy/storages"
+
+	"github.com/google/uuid"
+)
+
+func (s *SqliteWrapper) GetAllNamespace(ctx context.Context) ([]models.Namespace, error) {
+	query := "SELECT id, name FROM namespace ORDER BY name"
+
+	rows, err := s.GetData(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	namespaces := []models.Namespace{}
+
+	for rows.Next() {
+		namespace := models.Namespace{}
+		err = rows.Scan(&namespace.Id, &namespace.Name)
+		if err != nil {
+			return []models.Namespace{}, err
+		}
+		namespaces = append(namespaces, namespace)
+	}
+
+	return namespaces, nil
+}
+
+func (s *SqliteWrapper) GetNamespace(ctx context.Context, name string) (models.Namespace, error) {
+	query := "SELECT id, name FROM namespace WHERE name = $1"
+
+	rows, err := s.GetData(ctx, query, name)
+	if err != nil {
+		return models.Namespace{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Namespace{}, storages.ErrNotFound
+	}
+
+	namespace := models.Namespace{}
+	err = rows.Scan(&namespace.Id, &namespace.Name)
+	if err != nil {
+		return models.Namespace{}, err
+	}
+
+	return namespace, nil
+}
+
+func (s *SqliteWrapper) InsertNamespace(ctx context.Context, name string) error {
+	query := "INSERT INTO namespace (id, name) VALUES ($1, $2)"
+	id := uuid.NewString()
+
+	_, err := s.InsertData(ctx, query, id, name)
+	if isUniqueViolation(err) {
+		return storages.ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SqliteWrapper) DeleteNamespace(ctx context.Context, name string) error {
+	query := `
+		DELETE FROM namespace
+		WHERE name = $1
+		AND NOT EXISTS (SELECT 1 FROM configuration WHERE namespace = $1)
+	`
+
+	deleted, err := s.DeleteData(ctx, query, name)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		return nil
+	}
+
+	_, err = s.GetNamespace(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return storages.ErrConflict
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"livy/livy/models"
+	"livy/livy/storages"
+
+	"github.com/google/uuid"
+)
+
+func scanSchema(rows *sql.Rows) (models.Schema, error) {
+	schema := models.Schema{}
+	document := ""
+	err := rows.Scan(&schema.Id, &schema.Namespace, &schema.ConfigName, &document)
+	schema.Schema = []byte(document)
+
+	return schema, err
+}
+
+func (s *SqliteWrapper) GetAllSchema(ctx context.Context, namespace string) ([]models.Schema, error) {
+	query := "SELECT id, namespace, configname, schema FROM configuration_schema WHERE namespace = $1 ORDER BY configname"
+
+	rows, err := s.GetData(ctx, query, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	schemas := []models.Schema{}
+
+	for rows.Next() {
+		schema, err := scanSchema(rows)
+		if err != nil {
+			return []models.Schema{}, err
+		}
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, nil
+}
+
+func (s *SqliteWrapper) GetSchema(ctx context.Context, namespace, configname string) (models.Schema, error) {
+	query := "SELECT id, namespace, configname, schema FROM configuration_schema WHERE namespace = $1 AND configname = $2"
+
+	rows, err := s.GetData(ctx, query, namespace, configname)
+	if err != nil {
+		return models.Schema{}, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Schema{}, storages.ErrNotFound
+	}
+
+	return scanSchema(rows)
+}
+
+func (s *SqliteWrapper) UpsertSchema(ctx context.Context, schema models.Schema) (bool, error) {
+	_, err := s.GetNamespace(ctx, schema.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	update := "UPDATE configuration_schema SET schema = $1 WHERE namespace = $2 AND configname = $3"
+	insert := "INSERT INTO configuration_schema (id, namespace, configname, schema) VALUES ($1, $2, $3, $4)"
+
+	for {
+		updated, err := s.UpdateData(ctx, update, string(schema.Schema), schema.Namespace, schema.ConfigName)
+		if err != nil {
+			return false, err
+		}
+		if updated > 0 {
+			return false, nil
+		}
+
+		_, err = s.InsertData(ctx, insert, uuid.NewString(), schema.Namespace, schema.ConfigName, string(schema.Schema))
+		if err == nil {
+			return true, nil
+		}
+		// someone created it in between, update their row instead
+		if !isUniqueViolation(err) {
+			return false, err
+		}
+	}
+}
+
+func (s *SqliteWrapper) DeleteSchema(ctx context.Context, namespace, configname string) error {
+	query := "DELETE FROM configuration_schema WHERE namespace = $1 AND configname = $2"
+
+	deleted, err := s.DeleteData(ctx, query, namespace, configname)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storages.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"livy/livy/migrations"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"livy/livy/storages/sqlite"
+	"livy/livy/storages/storagetest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLivyRepo(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.LivyRepo {
+		db, err := sqlite.Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return db
+	})
+}
+
+func TestAddConfigurationUniqueName(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// version 2 creates the configuration table
+	require.NoError(t, migrations.New(db).Migrate(ctx, 2))
+	query := "INSERT INTO configuration (id, configname, value) VALUES ($1, $2, $3)"
+	for i, row := range [][]string{{"timeout", "10"}, {"timeout", "20"}, {"retries", "3"}} {
+		_, err = db.InsertData(ctx, query, fmt.Sprint(i), row[0], row[1])
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, migrations.New(db).Run(ctx))
+
+	configurations, err := db.GetAllConfiguration(ctx, models.DefaultNamespace, models.BaseEnvironment)
+	require.NoError(t, err)
+	require.Len(t, configurations, 2)
+
+	configuration, err := db.GetConfiguration(ctx, models.DefaultNamespace, models.BaseEnvironment, "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, "10", configuration.Value)
+	assert.Equal(t, models.TypeString, configuration.Type)
+	assert.Equal(t, 1, configuration.Version)
+
+	err = db.InsertConfiguration(ctx, models.Configuration{Namespace: models.DefaultNamespace, Environment: models.BaseEnvironment, ConfigName: "timeout", Value: "30"})
+	assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+}
+
+func TestCreateHistoryTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// version 8 creates the history table
+	require.NoError(t, migrations.New(db).Migrate(ctx, 7))
+
+	query := "INSERT INTO configuration (id, configname, value) VALUES ($1, $2, $3)"
+	_, err = db.InsertData(ctx, query, "1", "timeout", "10")
+	require.NoError(t, err)
+
+	require.NoError(t, migrations.New(db).Run(ctx))
+
+	revisions, err := db.GetConfigurationHistory(ctx, models.DefaultNamespace, models.BaseEnvironment, "timeout", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, models.ActionInsert, revisions[0].Action)
+	assert.Equal(t, "migration", revisions[0].Actor)
+	assert.Equal(t, "1", revisions[0].ConfigurationId)
+	assert.Equal(t, 0, revisions[0].Version)
+	require.NotNil(t, revisions[0].NewValue)
+	assert.Equal(t, "10", *revisions[0].NewValue)
+}
+
+func TestMigrationLockLease(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	unlock, err := db.LockMigrations(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+
+	// a process that is still migrating keeps the lock
+	live := time.Now().Add(time.Hour).UnixNano()
+	_, err = db.InsertData(ctx, "INSERT INTO migration_lock (name, locked_at, owner, expires_at) VALUES ('migrations', '', 'live', $1)", live)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = db.LockMigrations(waitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the lease of a killed process expires and is taken over
+	_, err = db.UpdateData(ctx, "UPDATE migration_lock SET owner = 'killed', expires_at = $1", time.Now().Add(-time.Second).UnixNano())
+	require.NoError(t, err)
+
+	unlock, err = db.LockMigrations(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+
+	rows, err := db.GetData(ctx, "SELECT owner FROM migration_lock")
+	require.NoError(t, err)
+	defer rows.Close()
+	assert.False(t, rows.Next())
+}
+
+func TestGetDBVersionInTx(t *testing.T) {
+	db, err := sqlite.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the pool has a single connection, held by the transaction
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.Atomic(ctx, func(repo storages.LivyRepo) error {
+		version, err := repo.GetDBVersion(ctx)
+		assert.Equal(t, 0, version)
+		return err
+	})
+	require.NoError(t, err)
+}
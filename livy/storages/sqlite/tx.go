@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"livy/livy/storages"
+)
+
+// conn is implemented by both *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (s *SqliteWrapper) conn() conn {
+	if s.tx != nil {
+		return s.tx
+	}
+
+	return s.db
+}
+
+// inTx runs fn with a copy of s bound to a transaction, committed when fn
+// returns nil and rolled back otherwise. Nested calls join the outer
+// transaction.
+func (s *SqliteWrapper) inTx(ctx context.Context, fn func(tx *SqliteWrapper) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(&SqliteWrapper{db: s.db, tx: tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SqliteWrapper) Atomic(ctx context.Context, fn func(repo storages.LivyRepo) error) error {
+	return s.inTx(ctx, func(tx *SqliteWrapper) error {
+		return fn(tx)
+	})
+}
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+type SqliteWrapper struct {
+	db *sql.DB
+	// tx is set on the copies handed out by inTx
+	tx *sql.Tx
+}
+
+func New() (*SqliteWrapper, error) {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "livy.db"
+	}
+
+	return Open(path)
+}
+
+// Open connects to the sqlite database at path, ":memory:" gives a private
+// in-memory database.
+func Open(path string) (*SqliteWrapper, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite allows a single writer, sharing one connection avoids SQLITE_BUSY
+	// and keeps ":memory:" databases alive across queries
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SqliteWrapper{
+		db: db,
+	}, nil
+}
+
+func (s *SqliteWrapper) Close() error {
+	return s.db.Close()
+}
+
+func (s *SqliteWrapper) GetData(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if query == "" {
+		return nil, fmt.Errorf("query can't be empty")
+	}
+
+	return s.conn().QueryContext(ctx, query, args...)
+}
+
+func (s *SqliteWrapper) InsertData(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if query == "" {
+		return 0, fmt.Errorf("query can't be empty")
+	}
+
+	result, err := s.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	insertedId, err := result.LastInsertId()
+	if err != nil {
+		return 0, nil
+	}
+
+	return insertedId, nil
+}
+
+func (s *SqliteWrapper) UpdateData(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if query == "" {
+		return 0, fmt.Errorf("query can't be empty")
+	}
+
+	result, err := s.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute update query: %w", err)
+	}
+
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+
+	return rowAffected, nil
+}
+
+func (s *SqliteWrapper) DeleteData(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	if query == "" {
+		return 0, fmt.Errorf("query can't be empty")
+	}
+
+	result, err := s.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute delete query: %w", err)
+	}
+
+	rowAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+
+	return rowAffected, nil
+}
+
+func (s *SqliteWrapper) CreateTable(ctx context.Context, tablename, schema string) error {
+	if schema == "" {
+		return fmt.Errorf("schema can't be empty")
+	}
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s);", tablename, schema)
+	_, err := s.conn().ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to create table: %v", err)
+	}
+
+	log.Printf("Table '%s' create successfully \n", tablename)
+	return nil
+}
@@ -3,27 +3,83 @@ package storages
 import (
 	"context"
 	"livy/livy/models"
+	"time"
 )
 
+// MigrationRepo records the applied migrations in the db_version table
 type MigrationRepo interface {
+	// InitiateTable creates db_version when missing and adds the columns older releases didn't have
 	InitiateTable(ctx context.Context) error
+	// GetDBVersion returns the highest applied version, 0 when none
 	GetDBVersion(ctx context.Context) (int, error)
-	InsertDBVersion(ctx context.Context, version int) error
+	// GetAllDBVersion returns the applied migrations sorted by version, none
+	// when db_version is missing. It never creates or alters the table.
+	GetAllDBVersion(ctx context.Context) ([]models.DBVersion, error)
+	InsertDBVersion(ctx context.Context, version models.DBVersion) error
+	DeleteDBVersion(ctx context.Context, version int) error
+	// Dialect names the directory of livy/migrations/sql holding the
+	// migrations of the repository, empty when it has no schema to migrate
+	Dialect() string
+	// ExecMigration runs the statements of a migration file
+	ExecMigration(ctx context.Context, statements string) error
+	// LockMigrations waits until no other caller, in any process, holds the
+	// migration lock or ctx is done, then takes it until unlock is called
+	LockMigrations(ctx context.Context) (unlock func() error, err error)
 }
 
-type DbMigrationRepo interface {
-	CreateConfigurationTable(ctx context.Context) error
+// ConfigurationRepo reports missing rows with ErrNotFound and name clashes
+// with ErrAlreadyExists. Names are unique within a namespace and environment.
+// Every change is recorded in the configuration history, in the same
+// transaction, attributed to the actor set with WithActor.
+type ConfigurationRepo interface {
+	GetAllConfiguration(ctx context.Context, namespace, environment string)([]models.Configuration,error)
+	// ListConfiguration returns at most query.Limit configurations matching query, in query.Sort order
+	ListConfiguration(ctx context.Context, query models.ConfigurationQuery) ([]models.Configuration, error)
+	GetConfiguration(ctx context.Context, namespace, environment, configname string)(models.Configuration, error)
+	GetConfigurationById(ctx context.Context, namespace, id string) (models.Configuration, error)
+	InsertConfiguration(ctx context.Context, configuration models.Configuration) error
+	// UpdateConfiguration changes the name, value and type of the configuration with the same id and namespace.
+	// A non zero Version must match the stored one, ErrStaleVersion otherwise.
+	UpdateConfiguration(ctx context.Context, configuration models.Configuration) error
+	// UpsertConfiguration sets the value and type of configname, creating it when missing.
+	// A non zero Version must match an existing configuration, ErrStaleVersion otherwise.
+	UpsertConfiguration(ctx context.Context, configuration models.Configuration) (created bool, err error)
+	DeleteConfiguration(ctx context.Context, namespace, id string) error
+	DeleteConfigurationByName(ctx context.Context, namespace, environment, configname string) error
+	// GetConfigurationHistory returns the revisions of configname, newest first
+	GetConfigurationHistory(ctx context.Context, namespace, environment, configname string, limit, offset int) ([]models.ConfigurationRevision, error)
+	GetConfigurationRevision(ctx context.Context, namespace, environment, configname string, revision int) (models.ConfigurationRevision, error)
+	// GetAllConfigurationAsOf rebuilds GetAllConfiguration as it was at asOf from the history, sorted by name
+	GetAllConfigurationAsOf(ctx context.Context, namespace, environment string, asOf time.Time) ([]models.Configuration, error)
 }
 
-type ConfigurationRepo interface {
-	GetAllConfiguration(ctx context.Context)([]models.Configuration,error)
-	GetConfiguration(ctx context.Context,configname string)(models.Configuration, error)
-	InsertConfiguration(ctx context.Context,configname,value string) error
-	UpdateConfiguration(ctx context.Context,configname,value,id string) error
+// NamespaceRepo refuses to delete a namespace that still holds configurations
+// with ErrConflict
+type NamespaceRepo interface {
+	GetAllNamespace(ctx context.Context) ([]models.Namespace, error)
+	GetNamespace(ctx context.Context, name string) (models.Namespace, error)
+	InsertNamespace(ctx context.Context, name string) error
+	DeleteNamespace(ctx context.Context, name string) error
+}
+
+// SchemaRepo keeps at most one schema per configuration name in a namespace,
+// schemas go away with their namespace
+type SchemaRepo interface {
+	GetAllSchema(ctx context.Context, namespace string) ([]models.Schema, error)
+	GetSchema(ctx context.Context, namespace, configname string) (models.Schema, error)
+	// UpsertSchema sets the schema of configname, creating it when missing
+	UpsertSchema(ctx context.Context, schema models.Schema) (created bool, err error)
+	DeleteSchema(ctx context.Context, namespace, configname string) error
 }
 
 type LivyRepo interface {
-	DbMigrationRepo
 	MigrationRepo
 	ConfigurationRepo
+	NamespaceRepo
+	SchemaRepo
+	// Atomic runs fn with a repository whose changes are committed together
+	// when fn returns nil and all rolled back otherwise. fn may run more than
+	// once when the storage retries a conflicting transaction, so it must
+	// reset any state it captures from outside.
+	Atomic(ctx context.Context, fn func(repo LivyRepo) error) error
 }
@@ -0,0 +1,1221 @@
+// Package storagetest is a conformance suite for storages.LivyRepo
+// implementations. Every backend runs it from its own tests so they all keep
+// the semantics the services rely on.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"livy/livy/migrations"
+	"livy/livy/models"
+	"livy/livy/storages"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty repository, the suite runs the migrations on it.
+type Factory func(t *testing.T) storages.LivyRepo
+
+// Run exercises repo against the LivyRepo contract. Subtests are not run in
+// parallel so factories may share a single database between them.
+func Run(t *testing.T, newRepo Factory) {
+	t.Run("migration", func(t *testing.T) { testMigration(t, newRepo) })
+	t.Run("migration lock", func(t *testing.T) { testMigrationLock(t, newRepo) })
+	t.Run("configuration", func(t *testing.T) { testConfiguration(t, newRepo) })
+	t.Run("namespace", func(t *testing.T) { testNamespace(t, newRepo) })
+	t.Run("environment", func(t *testing.T) { testEnvironment(t, newRepo) })
+	t.Run("list", func(t *testing.T) { testList(t, newRepo) })
+	t.Run("concurrency", func(t *testing.T) { testConcurrency(t, newRepo) })
+	t.Run("schema", func(t *testing.T) { testSchema(t, newRepo) })
+	t.Run("history", func(t *testing.T) { testHistory(t, newRepo) })
+	t.Run("atomic", func(t *testing.T) { testAtomic(t, newRepo) })
+}
+
+const (
+	ns  = models.DefaultNamespace
+	env = models.BaseEnvironment
+)
+
+func newConfiguration(id, configname, value string) models.Configuration {
+	return models.Configuration{
+		Id:          id,
+		Namespace:   ns,
+		Environment: env,
+		ConfigName:  configname,
+		Value:       value,
+		Type:        models.TypeString,
+	}
+}
+
+func setup(t *testing.T, newRepo Factory) storages.LivyRepo {
+	repo := newRepo(t)
+	err := migrations.New(repo).Run(context.Background())
+	require.NoError(t, err)
+
+	return repo
+}
+
+func testMigration(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	version, err := repo.GetDBVersion(ctx)
+	if err == nil {
+		assert.Equal(t, 0, version, "empty repository must report version 0")
+	}
+
+	err = migrations.New(repo).Run(ctx)
+	require.NoError(t, err)
+
+	version, err = repo.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, version, 1)
+
+	// running again is a no-op
+	err = migrations.New(repo).Run(ctx)
+	require.NoError(t, err)
+
+	again, err := repo.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, version, again)
+
+	versions, err := repo.GetAllDBVersion(ctx)
+	require.NoError(t, err)
+	require.Len(t, versions, version)
+	for i, applied := range versions {
+		assert.Equal(t, i+1, applied.Version)
+		assert.NotEmpty(t, applied.Name)
+		assert.NotEmpty(t, applied.Checksum)
+		assert.False(t, applied.AppliedAt.IsZero())
+	}
+
+	// revert some migrations and apply them again, the data stays
+	require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+	err = migrations.New(repo).Migrate(ctx, 3)
+	require.NoError(t, err)
+
+	again, err = repo.GetDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, again)
+
+	err = migrations.New(repo).Run(ctx)
+	require.NoError(t, err)
+
+	configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, "10", configuration.Value)
+	assert.Equal(t, models.TypeString, configuration.Type)
+	assert.Equal(t, 1, configuration.Version)
+
+	// revert everything
+	err = migrations.New(repo).Migrate(ctx, 0)
+	require.NoError(t, err)
+
+	versions, err = repo.GetAllDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	err = migrations.New(repo).Run(ctx)
+	require.NoError(t, err)
+
+	// a version this release doesn't know stops the run
+	err = repo.InsertDBVersion(ctx, models.DBVersion{Version: version + 1})
+	require.NoError(t, err)
+
+	err = migrations.New(repo).Run(ctx)
+	assert.ErrorIs(t, err, migrations.ErrUnknownVersion)
+
+	require.NoError(t, repo.DeleteDBVersion(ctx, version+1))
+	assert.ErrorIs(t, repo.DeleteDBVersion(ctx, version+1), storages.ErrNotFound)
+
+	// so does an applied migration edited afterwards
+	require.NoError(t, repo.DeleteDBVersion(ctx, version))
+	err = repo.InsertDBVersion(ctx, models.DBVersion{Version: version, Checksum: "edited"})
+	require.NoError(t, err)
+
+	err = migrations.New(repo).Run(ctx)
+	assert.ErrorIs(t, err, migrations.ErrChecksumMismatch)
+}
+
+func testMigrationLock(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	// instances starting together migrate once
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = migrations.New(repo).Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	versions, err := repo.GetAllDBVersion(ctx)
+	require.NoError(t, err)
+	assert.Len(t, versions, migrations.New(repo).Latest())
+
+	unlock, err := repo.LockMigrations(ctx)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = repo.LockMigrations(waitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = migrations.New(repo).WithLockTimeout(50 * time.Millisecond).Run(ctx)
+	assert.ErrorIs(t, err, migrations.ErrLockTimeout)
+
+	require.NoError(t, unlock())
+
+	unlock, err = repo.LockMigrations(ctx)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+}
+
+func testConfiguration(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		checkResult func(t *testing.T, repo storages.LivyRepo)
+	}{
+		{
+			name: "empty repository",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+			},
+		},
+		{
+			name: "insert and get",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+				assert.NotEmpty(t, configuration.Id)
+				assert.Equal(t, "timeout", configuration.ConfigName)
+				assert.Equal(t, "10", configuration.Value)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, configuration, configurations[0])
+			},
+		},
+		{
+			name: "malicious names are stored literally",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				names := []string{
+					"x' OR '1'='1",
+					"x'; DROP TABLE configuration; --",
+					`back\slash "quoted" $1`,
+				}
+				for _, name := range names {
+					require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", name, name)))
+
+					configuration, err := repo.GetConfiguration(ctx, ns, env, name)
+					require.NoError(t, err)
+					assert.Equal(t, name, configuration.ConfigName)
+					assert.Equal(t, name, configuration.Value)
+				}
+
+				_, err := repo.GetConfiguration(ctx, ns, env, "missing' OR '1'='1")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				_, err = repo.GetNamespace(ctx, "missing' OR '1'='1")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				assert.Len(t, configurations, len(names)+1)
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, names[0], 10, 0)
+				require.NoError(t, err)
+				assert.Len(t, revisions, 1)
+
+				require.NoError(t, repo.DeleteConfigurationByName(ctx, ns, env, names[0]))
+				_, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "type and get by id",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				configuration := newConfiguration("", "timeout", "10")
+				configuration.Type = models.TypeInt
+				require.NoError(t, repo.InsertConfiguration(ctx, configuration))
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+				assert.Equal(t, models.TypeInt, configuration.Type)
+
+				byId, err := repo.GetConfigurationById(ctx, ns, configuration.Id)
+				require.NoError(t, err)
+				assert.Equal(t, configuration, byId)
+
+				configuration.Type = models.TypeDuration
+				configuration.Value = "10s"
+				require.NoError(t, repo.UpdateConfiguration(ctx, configuration))
+
+				byId, err = repo.GetConfigurationById(ctx, ns, configuration.Id)
+				require.NoError(t, err)
+				assert.Equal(t, models.TypeDuration, byId.Type)
+
+				_, err = repo.GetConfigurationById(ctx, "other", configuration.Id)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				_, err = repo.GetConfigurationById(ctx, ns, "not-a-uuid")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "version compare and swap",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+				assert.Equal(t, 1, configuration.Version)
+
+				configuration.Value = "20"
+				require.NoError(t, repo.UpdateConfiguration(ctx, configuration))
+
+				configuration.Value = "30"
+				err = repo.UpdateConfiguration(ctx, configuration)
+				assert.ErrorIs(t, err, storages.ErrStaleVersion)
+
+				configuration.Version = 0
+				require.NoError(t, repo.UpdateConfiguration(ctx, configuration))
+
+				stale := newConfiguration("", "timeout", "40")
+				stale.Version = 2
+				_, err = repo.UpsertConfiguration(ctx, stale)
+				assert.ErrorIs(t, err, storages.ErrStaleVersion)
+
+				stale.Version = 3
+				created, err := repo.UpsertConfiguration(ctx, stale)
+				require.NoError(t, err)
+				assert.False(t, created)
+
+				missing := newConfiguration("", "retries", "3")
+				missing.Version = 1
+				_, err = repo.UpsertConfiguration(ctx, missing)
+				assert.ErrorIs(t, err, storages.ErrStaleVersion)
+
+				configuration, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+				assert.Equal(t, "40", configuration.Value)
+				assert.Equal(t, 4, configuration.Version)
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 4)
+				assert.Equal(t, 4, revisions[0].Version)
+				assert.Equal(t, 1, revisions[3].Version)
+			},
+		},
+		{
+			name: "missing name",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				_, err := repo.GetConfiguration(ctx, ns, env, "missing")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "empty value",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "empty", "")))
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "empty")
+				require.NoError(t, err)
+				assert.NotEmpty(t, configuration.Id)
+				assert.Equal(t, "", configuration.Value)
+			},
+		},
+		{
+			name: "unicode",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "grüße.名前", "värde 🚀\n\ttab")))
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "grüße.名前")
+				require.NoError(t, err)
+				assert.Equal(t, "grüße.名前", configuration.ConfigName)
+				assert.Equal(t, "värde 🚀\n\ttab", configuration.Value)
+			},
+		},
+		{
+			name: "update",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+
+				err = repo.UpdateConfiguration(ctx, newConfiguration(configuration.Id, "deadline", "30"))
+				require.NoError(t, err)
+
+				updated, err := repo.GetConfiguration(ctx, ns, env, "deadline")
+				require.NoError(t, err)
+				assert.Equal(t, configuration.Id, updated.Id)
+				assert.Equal(t, "30", updated.Value)
+
+				_, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "update unknown id",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				err := repo.UpdateConfiguration(ctx, newConfiguration("00000000-0000-0000-0000-000000000000", "timeout", "10"))
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				err = repo.UpdateConfiguration(ctx, newConfiguration("not-a-uuid", "timeout", "10"))
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+			},
+		},
+		{
+			name: "delete by id",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+
+				err = repo.DeleteConfiguration(ctx, ns, configuration.Id)
+				require.NoError(t, err)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, "retries", configurations[0].ConfigName)
+
+				err = repo.DeleteConfiguration(ctx, ns, configuration.Id)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "delete unknown id",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				err := repo.DeleteConfiguration(ctx, ns, "00000000-0000-0000-0000-000000000000")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				err = repo.DeleteConfiguration(ctx, ns, "not-a-uuid")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "delete by name",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				err := repo.DeleteConfigurationByName(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+
+				_, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				err = repo.DeleteConfigurationByName(ctx, ns, env, "timeout")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "duplicate names",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				err := repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "20"))
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+				assert.Equal(t, "10", configuration.Value)
+			},
+		},
+		{
+			name: "rename to existing name",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "retries")
+				require.NoError(t, err)
+
+				err = repo.UpdateConfiguration(ctx, newConfiguration(configuration.Id, "timeout", "3"))
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+			},
+		},
+		{
+			name: "upsert",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				created, err := repo.UpsertConfiguration(ctx, newConfiguration("", "timeout", "10"))
+				require.NoError(t, err)
+				assert.True(t, created)
+
+				created, err = repo.UpsertConfiguration(ctx, newConfiguration("", "timeout", "20"))
+				require.NoError(t, err)
+				assert.False(t, created)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, "20", configurations[0].Value)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setup(t, newRepo)
+			tc.checkResult(t, repo)
+		})
+	}
+}
+
+func testNamespace(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		checkResult func(t *testing.T, repo storages.LivyRepo)
+	}{
+		{
+			name: "default namespace exists",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				namespace, err := repo.GetNamespace(ctx, models.DefaultNamespace)
+				require.NoError(t, err)
+				assert.NotEmpty(t, namespace.Id)
+
+				namespaces, err := repo.GetAllNamespace(ctx)
+				require.NoError(t, err)
+				require.Len(t, namespaces, 1)
+				assert.Equal(t, models.DefaultNamespace, namespaces[0].Name)
+			},
+		},
+		{
+			name: "insert, list and duplicate",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertNamespace(ctx, "payments"))
+				require.NoError(t, repo.InsertNamespace(ctx, "billing"))
+
+				err := repo.InsertNamespace(ctx, "payments")
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+				namespaces, err := repo.GetAllNamespace(ctx)
+				require.NoError(t, err)
+				require.Len(t, namespaces, 3)
+				assert.Equal(t, "billing", namespaces[0].Name)
+				assert.Equal(t, "default", namespaces[1].Name)
+				assert.Equal(t, "payments", namespaces[2].Name)
+
+				_, err = repo.GetNamespace(ctx, "missing")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "same name in two namespaces",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertNamespace(ctx, "payments"))
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+
+				other := newConfiguration("", "timeout", "99")
+				other.Namespace = "payments"
+				require.NoError(t, repo.InsertConfiguration(ctx, other))
+
+				configuration, err := repo.GetConfiguration(ctx, "payments", env, "timeout")
+				require.NoError(t, err)
+				assert.Equal(t, "payments", configuration.Namespace)
+				assert.Equal(t, "99", configuration.Value)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, "10", configurations[0].Value)
+
+				// ids don't leak across namespaces
+				err = repo.DeleteConfiguration(ctx, ns, configuration.Id)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				err = repo.UpdateConfiguration(ctx, newConfiguration(configuration.Id, "timeout", "1"))
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "insert into unknown namespace",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				configuration := newConfiguration("", "timeout", "10")
+				configuration.Namespace = "missing"
+
+				err := repo.InsertConfiguration(ctx, configuration)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "delete",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertNamespace(ctx, "payments"))
+				configuration := newConfiguration("", "timeout", "10")
+				configuration.Namespace = "payments"
+				require.NoError(t, repo.InsertConfiguration(ctx, configuration))
+
+				err := repo.DeleteNamespace(ctx, "payments")
+				assert.ErrorIs(t, err, storages.ErrConflict)
+
+				require.NoError(t, repo.DeleteConfigurationByName(ctx, "payments", env, "timeout"))
+				require.NoError(t, repo.DeleteNamespace(ctx, "payments"))
+
+				err = repo.DeleteNamespace(ctx, "payments")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setup(t, newRepo)
+			tc.checkResult(t, repo)
+		})
+	}
+}
+
+func testEnvironment(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := setup(t, newRepo)
+
+	prod := newConfiguration("", "timeout", "30")
+	prod.Environment = "prod"
+
+	require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+	require.NoError(t, repo.InsertConfiguration(ctx, prod))
+
+	err := repo.InsertConfiguration(ctx, prod)
+	assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+	configuration, err := repo.GetConfiguration(ctx, ns, "prod", "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", configuration.Environment)
+	assert.Equal(t, "30", configuration.Value)
+
+	configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+	require.NoError(t, err)
+	require.Len(t, configurations, 1)
+	assert.Equal(t, "10", configurations[0].Value)
+
+	_, err = repo.GetConfiguration(ctx, ns, "staging", "timeout")
+	assert.ErrorIs(t, err, storages.ErrNotFound)
+
+	prod.Value = "60"
+	created, err := repo.UpsertConfiguration(ctx, prod)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	// renaming keeps the environment
+	prod.Id = configuration.Id
+	prod.ConfigName = "deadline"
+	require.NoError(t, repo.UpdateConfiguration(ctx, prod))
+
+	configuration, err = repo.GetConfiguration(ctx, ns, "prod", "deadline")
+	require.NoError(t, err)
+	assert.Equal(t, "60", configuration.Value)
+
+	require.NoError(t, repo.DeleteConfigurationByName(ctx, ns, "prod", "deadline"))
+
+	configuration, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, "10", configuration.Value)
+}
+
+func testList(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := setup(t, newRepo)
+
+	for _, configname := range []string{"db.host", "db.port", "cache.ttl", "Db.user"} {
+		require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", configname, "value")))
+	}
+	prod := newConfiguration("", "db.host", "value")
+	prod.Environment = "prod"
+	require.NoError(t, repo.InsertConfiguration(ctx, prod))
+
+	// db.port ends at version 3 and cache.ttl at version 2
+	for _, configname := range []string{"db.port", "db.port", "cache.ttl"} {
+		configuration, err := repo.GetConfiguration(ctx, ns, env, configname)
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateConfiguration(ctx, newConfiguration(configuration.Id, configname, "updated")))
+	}
+
+	names := func(configurations []models.Configuration) []string {
+		result := []string{}
+		for _, configuration := range configurations {
+			result = append(result, configuration.ConfigName)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		query    models.ConfigurationQuery
+		expected []string
+	}{
+		{
+			name:     "sorted by name, byte order",
+			query:    models.ConfigurationQuery{Sort: models.SortName},
+			expected: []string{"Db.user", "cache.ttl", "db.host", "db.port"},
+		},
+		{
+			name:     "sorted by name descending",
+			query:    models.ConfigurationQuery{Sort: models.SortNameDesc},
+			expected: []string{"db.port", "db.host", "cache.ttl", "Db.user"},
+		},
+		{
+			name:     "sorted by version then name",
+			query:    models.ConfigurationQuery{Sort: models.SortVersion},
+			expected: []string{"Db.user", "db.host", "cache.ttl", "db.port"},
+		},
+		{
+			name:     "sorted by version descending",
+			query:    models.ConfigurationQuery{Sort: models.SortVersionDesc},
+			expected: []string{"db.port", "cache.ttl", "db.host", "Db.user"},
+		},
+		{
+			name:     "prefix is case sensitive",
+			query:    models.ConfigurationQuery{Sort: models.SortName, Prefix: "db."},
+			expected: []string{"db.host", "db.port"},
+		},
+		{
+			name:     "contains",
+			query:    models.ConfigurationQuery{Sort: models.SortName, Contains: "o"},
+			expected: []string{"db.host", "db.port"},
+		},
+		{
+			name:     "wildcards are matched literally",
+			query:    models.ConfigurationQuery{Sort: models.SortName, Prefix: "db%", Contains: "_"},
+			expected: []string{},
+		},
+		{
+			name:     "limit",
+			query:    models.ConfigurationQuery{Sort: models.SortName, Limit: 2},
+			expected: []string{"Db.user", "cache.ttl"},
+		},
+		{
+			name: "after a name",
+			query: models.ConfigurationQuery{
+				Sort:  models.SortName,
+				After: &models.Configuration{ConfigName: "cache.ttl"},
+			},
+			expected: []string{"db.host", "db.port"},
+		},
+		{
+			name: "after a name descending",
+			query: models.ConfigurationQuery{
+				Sort:  models.SortNameDesc,
+				After: &models.Configuration{ConfigName: "db.host"},
+			},
+			expected: []string{"cache.ttl", "Db.user"},
+		},
+		{
+			name: "after a version ties on the name",
+			query: models.ConfigurationQuery{
+				Sort:  models.SortVersion,
+				After: &models.Configuration{ConfigName: "Db.user", Version: 1},
+				Limit: 2,
+			},
+			expected: []string{"db.host", "cache.ttl"},
+		},
+		{
+			name: "after a version descending",
+			query: models.ConfigurationQuery{
+				Sort:  models.SortVersionDesc,
+				After: &models.Configuration{ConfigName: "cache.ttl", Version: 2},
+			},
+			expected: []string{"db.host", "Db.user"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := tc.query
+			query.Namespace = ns
+			query.Environment = env
+			if query.Limit == 0 {
+				query.Limit = 10
+			}
+
+			configurations, err := repo.ListConfiguration(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, names(configurations))
+		})
+	}
+}
+
+func testConcurrency(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	repo := setup(t, newRepo)
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.InsertConfiguration(ctx, newConfiguration("", fmt.Sprintf("key-%d", i), fmt.Sprint(i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+	require.NoError(t, err)
+	require.Len(t, configurations, writers)
+
+	// concurrent updates of the same row must all succeed, one of them wins
+	target, err := repo.GetConfiguration(ctx, ns, env, "key-0")
+	require.NoError(t, err)
+
+	errs = make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.UpdateConfiguration(ctx, newConfiguration(target.Id, "key-0", fmt.Sprint(i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	configuration, err := repo.GetConfiguration(ctx, ns, env, "key-0")
+	require.NoError(t, err)
+	assert.Equal(t, target.Id, configuration.Id)
+
+	// concurrent upserts of a new name create it exactly once
+	created := make(chan bool, writers)
+	errs = make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := repo.UpsertConfiguration(ctx, newConfiguration("", "shared", fmt.Sprint(i)))
+			created <- ok
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(created)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	creations := 0
+	for ok := range created {
+		if ok {
+			creations++
+		}
+	}
+	assert.Equal(t, 1, creations)
+}
+
+func testSchema(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	document := []byte(`{"type":"object","required":["host"]}`)
+
+	tests := []struct {
+		name        string
+		checkResult func(t *testing.T, repo storages.LivyRepo)
+	}{
+		{
+			name: "upsert, get and list",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				schemas, err := repo.GetAllSchema(ctx, ns)
+				require.NoError(t, err)
+				assert.Empty(t, schemas)
+
+				created, err := repo.UpsertSchema(ctx, models.Schema{Namespace: ns, ConfigName: "db", Schema: document})
+				require.NoError(t, err)
+				assert.True(t, created)
+
+				created, err = repo.UpsertSchema(ctx, models.Schema{Namespace: ns, ConfigName: "db", Schema: []byte(`{"type":"object"}`)})
+				require.NoError(t, err)
+				assert.False(t, created)
+
+				_, err = repo.UpsertSchema(ctx, models.Schema{Namespace: ns, ConfigName: "cache", Schema: document})
+				require.NoError(t, err)
+
+				schema, err := repo.GetSchema(ctx, ns, "db")
+				require.NoError(t, err)
+				assert.NotEmpty(t, schema.Id)
+				assert.JSONEq(t, `{"type":"object"}`, string(schema.Schema))
+
+				schemas, err = repo.GetAllSchema(ctx, ns)
+				require.NoError(t, err)
+				require.Len(t, schemas, 2)
+				assert.Equal(t, "cache", schemas[0].ConfigName)
+				assert.Equal(t, "db", schemas[1].ConfigName)
+
+				_, err = repo.GetSchema(ctx, ns, "missing")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "unknown namespace",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				_, err := repo.UpsertSchema(ctx, models.Schema{Namespace: "missing", ConfigName: "db", Schema: document})
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "delete",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				_, err := repo.UpsertSchema(ctx, models.Schema{Namespace: ns, ConfigName: "db", Schema: document})
+				require.NoError(t, err)
+
+				require.NoError(t, repo.DeleteSchema(ctx, ns, "db"))
+
+				err = repo.DeleteSchema(ctx, ns, "db")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "deleted with their namespace",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertNamespace(ctx, "payments"))
+				_, err := repo.UpsertSchema(ctx, models.Schema{Namespace: "payments", ConfigName: "db", Schema: document})
+				require.NoError(t, err)
+
+				require.NoError(t, repo.DeleteNamespace(ctx, "payments"))
+				require.NoError(t, repo.InsertNamespace(ctx, "payments"))
+
+				_, err = repo.GetSchema(ctx, "payments", "db")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setup(t, newRepo)
+			tc.checkResult(t, repo)
+		})
+	}
+}
+
+func testHistory(t *testing.T, newRepo Factory) {
+	ctx := storages.WithActor(context.Background(), "alice")
+
+	value := func(value string) *string { return &value }
+
+	tests := []struct {
+		name        string
+		checkResult func(t *testing.T, repo storages.LivyRepo)
+	}{
+		{
+			name: "every change is recorded",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+
+				configuration.Value = "20"
+				require.NoError(t, repo.UpdateConfiguration(ctx, configuration))
+
+				_, err = repo.UpsertConfiguration(storages.WithActor(ctx, "bob"), newConfiguration("", "timeout", "30"))
+				require.NoError(t, err)
+
+				require.NoError(t, repo.DeleteConfigurationByName(ctx, ns, env, "timeout"))
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 4)
+
+				expected := []struct {
+					revision int
+					action   string
+					oldValue *string
+					newValue *string
+					actor    string
+				}{
+					{4, models.ActionDelete, value("30"), nil, "alice"},
+					{3, models.ActionUpdate, value("20"), value("30"), "bob"},
+					{2, models.ActionUpdate, value("10"), value("20"), "alice"},
+					{1, models.ActionInsert, nil, value("10"), "alice"},
+				}
+				for i, e := range expected {
+					assert.Equal(t, e.revision, revisions[i].Revision)
+					assert.Equal(t, e.action, revisions[i].Action)
+					assert.Equal(t, e.oldValue, revisions[i].OldValue)
+					assert.Equal(t, e.newValue, revisions[i].NewValue)
+					assert.Equal(t, e.actor, revisions[i].Actor)
+					assert.Equal(t, models.TypeString, revisions[i].Type)
+					assert.False(t, revisions[i].ChangedAt.IsZero())
+				}
+				assert.False(t, revisions[0].ChangedAt.Before(revisions[3].ChangedAt))
+
+				// a new configuration with the same name continues the history
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "40")))
+				revisions, err = repo.GetConfigurationHistory(ctx, ns, env, "timeout", 1, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 1)
+				assert.Equal(t, 5, revisions[0].Revision)
+			},
+		},
+		{
+			name: "pagination",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				for i := 0; i < 5; i++ {
+					_, err := repo.UpsertConfiguration(ctx, newConfiguration("", "timeout", fmt.Sprint(i)))
+					require.NoError(t, err)
+				}
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 2, 1)
+				require.NoError(t, err)
+				require.Len(t, revisions, 2)
+				assert.Equal(t, 4, revisions[0].Revision)
+				assert.Equal(t, 3, revisions[1].Revision)
+
+				revisions, err = repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 5)
+				require.NoError(t, err)
+				assert.Empty(t, revisions)
+			},
+		},
+		{
+			name: "rename",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "timeout")
+				require.NoError(t, err)
+
+				configuration.ConfigName = "deadline"
+				require.NoError(t, repo.UpdateConfiguration(ctx, configuration))
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 2)
+				assert.Equal(t, models.ActionDelete, revisions[0].Action)
+
+				revisions, err = repo.GetConfigurationHistory(ctx, ns, env, "deadline", 10, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 1)
+				assert.Equal(t, models.ActionInsert, revisions[0].Action)
+				assert.Equal(t, value("10"), revisions[0].NewValue)
+			},
+		},
+		{
+			name: "failed changes are not recorded",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+
+				err := repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "20"))
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+				configuration, err := repo.GetConfiguration(ctx, ns, env, "retries")
+				require.NoError(t, err)
+				configuration.ConfigName = "timeout"
+				err = repo.UpdateConfiguration(ctx, configuration)
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				assert.Len(t, revisions, 1)
+
+				revisions, err = repo.GetConfigurationHistory(ctx, ns, env, "retries", 10, 0)
+				require.NoError(t, err)
+				assert.Len(t, revisions, 1)
+			},
+		},
+		{
+			name: "get revision",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				_, err := repo.UpsertConfiguration(ctx, newConfiguration("", "timeout", "20"))
+				require.NoError(t, err)
+
+				revision, err := repo.GetConfigurationRevision(ctx, ns, env, "timeout", 1)
+				require.NoError(t, err)
+				assert.Equal(t, 1, revision.Revision)
+				assert.Equal(t, value("10"), revision.NewValue)
+
+				_, err = repo.GetConfigurationRevision(ctx, ns, env, "timeout", 3)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+
+				_, err = repo.GetConfigurationRevision(ctx, ns, "prod", "timeout", 1)
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+		{
+			name: "as of",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				// timestamps are kept to the microsecond, leave room between changes
+				tick := func() time.Time {
+					time.Sleep(2 * time.Millisecond)
+					now := time.Now()
+					time.Sleep(2 * time.Millisecond)
+					return now
+				}
+
+				beforeAll := tick()
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+				afterInsert := tick()
+				_, err := repo.UpsertConfiguration(ctx, newConfiguration("", "timeout", "20"))
+				require.NoError(t, err)
+				require.NoError(t, repo.DeleteConfigurationByName(ctx, ns, env, "retries"))
+				afterDelete := tick()
+
+				configurations, err := repo.GetAllConfigurationAsOf(ctx, ns, env, beforeAll)
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+
+				configurations, err = repo.GetAllConfigurationAsOf(ctx, ns, env, afterInsert)
+				require.NoError(t, err)
+				require.Len(t, configurations, 2)
+				assert.Equal(t, "retries", configurations[0].ConfigName)
+				assert.Equal(t, "3", configurations[0].Value)
+				assert.Equal(t, "timeout", configurations[1].ConfigName)
+				assert.Equal(t, "10", configurations[1].Value)
+
+				current, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+
+				configurations, err = repo.GetAllConfigurationAsOf(ctx, ns, env, afterDelete)
+				require.NoError(t, err)
+				assert.Equal(t, current, configurations)
+
+				configurations, err = repo.GetAllConfigurationAsOf(ctx, ns, "prod", afterDelete)
+				require.NoError(t, err)
+				assert.Empty(t, configurations)
+			},
+		},
+		{
+			name: "unknown actor",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(context.Background(), newConfiguration("", "timeout", "10")))
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				require.Len(t, revisions, 1)
+				assert.Equal(t, storages.UnknownActor, revisions[0].Actor)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setup(t, newRepo)
+			tc.checkResult(t, repo)
+		})
+	}
+}
+
+func testAtomic(t *testing.T, newRepo Factory) {
+	ctx := context.Background()
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name        string
+		checkResult func(t *testing.T, repo storages.LivyRepo)
+	}{
+		{
+			name: "commit",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+
+				err := repo.Atomic(ctx, func(tx storages.LivyRepo) error {
+					require.NoError(t, tx.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+					require.NoError(t, tx.DeleteConfigurationByName(ctx, ns, env, "retries"))
+
+					// the transaction sees its own changes
+					_, err := tx.GetConfiguration(ctx, ns, env, "timeout")
+					require.NoError(t, err)
+
+					return nil
+				})
+				require.NoError(t, err)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, "timeout", configurations[0].ConfigName)
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "retries", 10, 0)
+				require.NoError(t, err)
+				assert.Len(t, revisions, 2)
+			},
+		},
+		{
+			name: "rollback",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+
+				err := repo.Atomic(ctx, func(tx storages.LivyRepo) error {
+					require.NoError(t, tx.InsertConfiguration(ctx, newConfiguration("", "timeout", "10")))
+					require.NoError(t, tx.DeleteConfigurationByName(ctx, ns, env, "retries"))
+					return failure
+				})
+				assert.ErrorIs(t, err, failure)
+
+				configurations, err := repo.GetAllConfiguration(ctx, ns, env)
+				require.NoError(t, err)
+				require.Len(t, configurations, 1)
+				assert.Equal(t, "retries", configurations[0].ConfigName)
+
+				revisions, err := repo.GetConfigurationHistory(ctx, ns, env, "timeout", 10, 0)
+				require.NoError(t, err)
+				assert.Empty(t, revisions)
+			},
+		},
+		{
+			name: "rollback after a failed change",
+			checkResult: func(t *testing.T, repo storages.LivyRepo) {
+				require.NoError(t, repo.InsertConfiguration(ctx, newConfiguration("", "retries", "3")))
+
+				err := repo.Atomic(ctx, func(tx storages.LivyRepo) error {
+					err := tx.InsertConfiguration(ctx, newConfiguration("", "timeout", "10"))
+					if err != nil {
+						return err
+					}
+					return tx.InsertConfiguration(ctx, newConfiguration("", "retries", "4"))
+				})
+				assert.ErrorIs(t, err, storages.ErrAlreadyExists)
+
+				_, err = repo.GetConfiguration(ctx, ns, env, "timeout")
+				assert.ErrorIs(t, err, storages.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := setup(t, newRepo)
+			tc.checkResult(t, repo)
+		})
+	}
+}
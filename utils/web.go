@@ -9,9 +9,16 @@ type WebResponse struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	// NextCursor is set on paged lists with more items after Data
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func WriteJSON(w http.ResponseWriter, status int, message string, data any) error {
+	return WritePage(w, status, message, data, "")
+}
+
+// WritePage writes data like WriteJSON along with the cursor of the next page
+func WritePage(w http.ResponseWriter, status int, message string, data any, nextCursor string) error {
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(status)
 
@@ -19,6 +26,7 @@ func WriteJSON(w http.ResponseWriter, status int, message string, data any) erro
 	response.Status = status
 	response.Message = message
 	response.Data = data
+	response.NextCursor = nextCursor
 
 	return json.NewEncoder(w).Encode(response)
 }
\ No newline at end of file